@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nagSymbols maps move annotation symbols to their PGN NAG numbers.
+var nagSymbols = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// normalizeNAG accepts a symbol such as "!?" or a PGN NAG such as "$5" and
+// returns the symbol when there is one.
+func normalizeNAG(nag string) (string, error) {
+	if _, ok := nagSymbols[nag]; ok {
+		return nag, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(nag, "$")); err == nil && strings.HasPrefix(nag, "$") && n >= 0 && n <= 255 {
+		for symbol, value := range nagSymbols {
+			if value == n {
+				return symbol, nil
+			}
+		}
+		return nag, nil
+	}
+	return "", fmt.Errorf("unknown annotation %q, use !, ?, !!, ??, !? or ?!", nag)
+}
+
+// annotatedSAN is the move's SAN followed by its annotation, e.g. "Nf3!?".
+func (m Move) annotatedSAN() string {
+	if strings.HasPrefix(m.nag, "$") {
+		return m.san + " " + m.nag
+	}
+	return m.san + m.nag
+}
+
+// annotate sets the annotation symbol of the move that led to the current
+// position; an empty nag clears it.
+func (c *ChessGame) annotate(nag string) error {
+	if c.current.parent == nil {
+		return fmt.Errorf("no move to annotate yet")
+	}
+	if nag == "" {
+		c.current.move.nag = ""
+		return nil
+	}
+	symbol, err := normalizeNAG(nag)
+	if err != nil {
+		return err
+	}
+	c.current.move.nag = symbol
+	return nil
+}
+
+// comment sets the text comment of the move that led to the current
+// position, or of the game itself at the start; empty text clears it.
+func (c *ChessGame) comment(text string) {
+	c.current.move.comment = strings.NewReplacer("{", "(", "}", ")").Replace(strings.TrimSpace(text))
+}
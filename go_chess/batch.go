@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BatchOutcome is what batch mode prints when it is done, as one JSON
+// object.
+type BatchOutcome struct {
+	FEN         string       `json:"fen"`
+	Result      string       `json:"result"`
+	Termination Termination  `json:"termination,omitempty"`
+	Moves       int          `json:"moves"`
+	IllegalMove *IllegalMove `json:"illegal_move,omitempty"`
+}
+
+type IllegalMove struct {
+	Line   int    `json:"line"`
+	Move   string `json:"move"`
+	Reason string `json:"reason"`
+}
+
+// runBatch plays the moves read from r without any screen output and
+// stops at the first move that cannot be played. The input is read as PGN
+// movetext, so move numbers (also glued, as in "1.e4"), comments, NAGs,
+// results, tags and variations are skipped. Moves may be SAN or squares,
+// several to a line, and a move typed as in the REPL, "e2 e4", is read as
+// one. Lines starting with # are skipped.
+func runBatch(r io.Reader, w io.Writer) (BatchOutcome, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return BatchOutcome{}, err
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines[i] = ""
+		}
+	}
+	tokens, err := tokenizePGN(strings.Join(lines, "\n"))
+	if err != nil {
+		return BatchOutcome{}, err
+	}
+
+	game := NewChessGame()
+	var outcome BatchOutcome
+	depth := 0
+	for i := 0; i < len(tokens) && outcome.IllegalMove == nil; i++ {
+		tok := tokens[i]
+		switch {
+		case tok.kind == pgnOpen:
+			depth++
+			continue
+		case tok.kind == pgnClose && depth > 0:
+			depth--
+			continue
+		case tok.kind != pgnMove || depth > 0:
+			continue
+		}
+
+		move := tok.text
+		if i+1 < len(tokens) && game.squarePair(tok, tokens[i+1]) {
+			move += " " + tokens[i+1].text
+			i++
+		}
+		from, to, err := game.resolveMove(move)
+		if game.isOver() {
+			err = fmt.Errorf("the game is over")
+		}
+		if err != nil {
+			outcome.IllegalMove = &IllegalMove{Line: tok.line, Move: move, Reason: err.Error()}
+			break
+		}
+		game.movePiece(from, to)
+		outcome.Moves++
+	}
+
+	outcome.FEN = game.fen()
+	outcome.Result = game.resultTag()
+	outcome.Termination = game.termination
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return outcome, enc.Encode(outcome)
+}
+
+// squarePair reports whether the move tokens a and b on one line are the
+// from and to squares of a single move, as in "e2 e4". A lone square is
+// SAN for a pawn moving onto that empty square, so a square with a piece
+// on it can only be the start of a pair.
+func (c *ChessGame) squarePair(a, b pgnToken) bool {
+	if b.kind != pgnMove || a.line != b.line {
+		return false
+	}
+	fromRow, fromCol := parsePosition(a.text)
+	toRow, _ := parsePosition(b.text)
+	return fromRow != -1 && toRow != -1 && c.board[fromRow][fromCol] != ""
+}
+
+func isResultToken(token string) bool {
+	return token == WhiteWins || token == BlackWins || token == Draw || token == NoResult
+}
+
+// Exit codes of batch mode.
+const (
+	batchOK      = 0
+	batchIllegal = 1
+	batchError   = 2
+)
+
+func batchMain(path string) int {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return batchError
+		}
+		defer f.Close()
+		in = f
+	}
+
+	outcome, err := runBatch(in, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return batchError
+	}
+	if outcome.IllegalMove != nil {
+		return batchIllegal
+	}
+	return batchOK
+}
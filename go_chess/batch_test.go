@@ -0,0 +1,42 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantMoves   int
+		wantIllegal string
+		wantResult  string
+	}{
+		{"SAN", "e4 e5\nNf3 Nc6\n", 4, "", NoResult},
+		{"squares as in the REPL", "e2 e4\ne7-e5\ng1f3\n", 3, "", NoResult},
+		{"several square pairs to a line", "e2 e4 e7 e5 g1 f3", 3, "", NoResult},
+		{"PGN movetext", "1.e4 e5 2.Nf3 {develops} Nc6!? (2...d6) 3.Bb5 *", 5, "", NoResult},
+		{"comment lines", "# Scholar's mate\ne4 e5 Bc4 Nc6 Qh5 Nf6 Qxf7#\n", 7, "", WhiteWins},
+		{"wrong side", "e2 e4\ne4 e5\n", 1, "e4 e5", NoResult},
+		{"illegal SAN", "1. e4 e5 2. Ke3", 2, "Ke3", NoResult},
+		{"move after mate", "1. f3 e5 2. g4 Qh4# 3. a3", 4, "a3", BlackWins},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := runBatch(strings.NewReader(tt.input), io.Discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			illegal := ""
+			if outcome.IllegalMove != nil {
+				illegal = outcome.IllegalMove.Move
+			}
+			if outcome.Moves != tt.wantMoves || illegal != tt.wantIllegal || outcome.Result != tt.wantResult {
+				t.Errorf("got %d moves, illegal %q, result %s; want %d, %q, %s",
+					outcome.Moves, illegal, outcome.Result, tt.wantMoves, tt.wantIllegal, tt.wantResult)
+			}
+		})
+	}
+}
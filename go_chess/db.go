@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// openingPlies is how many moves name an opening when a game has no ECO
+// or Opening tag.
+const openingPlies = 6
+
+// DBGame is one game in the local database, reduced to its tags, result
+// and main line.
+type DBGame struct {
+	ID     int               `json:"id"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	Result string            `json:"result"`
+	FEN    string            `json:"fen,omitempty"`
+	Moves  []string          `json:"moves"`
+}
+
+// GameDB stores games with an index from the Zobrist key of every
+// position on their main line to the games it occurred in.
+type GameDB struct {
+	Games     []DBGame         `json:"games"`
+	Positions map[string][]int `json:"positions"`
+}
+
+// PositionStats summarizes the games in which a position occurred.
+type PositionStats struct {
+	Games, WhiteWins, Draws, BlackWins int
+}
+
+func getGameDBPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "games-db.json"
+	}
+	dataDir := filepath.Join(homeDir, ".local", "share", "go-chess")
+	os.MkdirAll(dataDir, 0755)
+	return filepath.Join(dataDir, "games-db.json")
+}
+
+func loadGameDB(path string) (*GameDB, error) {
+	db := &GameDB{Positions: map[string][]int{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, db); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if db.Positions == nil {
+		db.Positions = map[string][]int{}
+	}
+	return db, nil
+}
+
+func saveGameDB(path string, db *GameDB) error {
+	data, err := json.Marshal(db)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func zobristKey(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// add stores the main line of game and indexes its positions. It reports
+// false when the same game is already in the database.
+func (db *GameDB) add(game *ChessGame) bool {
+	entry := DBGame{ID: len(db.Games) + 1, Tags: game.tags, Result: game.resultTag()}
+	if start := game.startFEN(); start != standardStartFEN {
+		entry.FEN = start
+	}
+
+	replay := &ChessGame{board: game.startBoard, whiteToMove: game.startWhiteToMove}
+	keys := []string{zobristKey(replay.zobrist())}
+	for node := game.root; len(node.children) > 0; {
+		node = node.children[0]
+		replay.applyMove(node.move)
+		entry.Moves = append(entry.Moves, node.move.san)
+		keys = append(keys, zobristKey(replay.zobrist()))
+	}
+
+	for _, g := range db.Games {
+		if g.sameAs(entry) {
+			return false
+		}
+	}
+
+	db.Games = append(db.Games, entry)
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			db.Positions[key] = append(db.Positions[key], entry.ID)
+		}
+	}
+	return true
+}
+
+func (g DBGame) sameAs(other DBGame) bool {
+	return g.FEN == other.FEN && g.Result == other.Result &&
+		g.Tags["White"] == other.Tags["White"] && g.Tags["Black"] == other.Tags["Black"] &&
+		g.Tags["Date"] == other.Tags["Date"] &&
+		strings.Join(g.Moves, " ") == strings.Join(other.Moves, " ")
+}
+
+func (db *GameDB) game(id int) DBGame {
+	return db.Games[id-1]
+}
+
+// gamesWithPosition returns the games in which the position occurred.
+func (db *GameDB) gamesWithPosition(hash uint64) []DBGame {
+	var games []DBGame
+	for _, id := range db.Positions[zobristKey(hash)] {
+		games = append(games, db.game(id))
+	}
+	return games
+}
+
+// gamesByPlayer returns the games where name is part of White or Black.
+func (db *GameDB) gamesByPlayer(name string) []DBGame {
+	name = strings.ToLower(name)
+	var games []DBGame
+	for _, g := range db.Games {
+		if strings.Contains(strings.ToLower(g.Tags["White"]), name) || strings.Contains(strings.ToLower(g.Tags["Black"]), name) {
+			games = append(games, g)
+		}
+	}
+	return games
+}
+
+func (db *GameDB) positionStats(hash uint64) PositionStats {
+	return summarize(db.gamesWithPosition(hash))
+}
+
+func summarize(games []DBGame) PositionStats {
+	var stats PositionStats
+	for _, g := range games {
+		stats.Games++
+		switch g.Result {
+		case WhiteWins:
+			stats.WhiteWins++
+		case BlackWins:
+			stats.BlackWins++
+		case Draw:
+			stats.Draws++
+		}
+	}
+	return stats
+}
+
+func (s PositionStats) String() string {
+	return fmt.Sprintf("%d games, +%d =%d -%d", s.Games, s.WhiteWins, s.Draws, s.BlackWins)
+}
+
+// opening names the opening of a game by its ECO and Opening tags, or by
+// its first moves when it has neither.
+func (g DBGame) opening() string {
+	name := strings.TrimSpace(g.Tags["ECO"] + " " + g.Tags["Opening"])
+	if name != "" {
+		return name
+	}
+	moves := g.Moves
+	if len(moves) > openingPlies {
+		moves = moves[:openingPlies]
+	}
+	if len(moves) == 0 {
+		return "(no moves)"
+	}
+	return strings.Join(moves, " ")
+}
+
+func (g DBGame) String() string {
+	white, black := g.Tags["White"], g.Tags["Black"]
+	if white == "" {
+		white = "?"
+	}
+	if black == "" {
+		black = "?"
+	}
+	s := fmt.Sprintf("#%d %s - %s %s, %d moves", g.ID, white, black, g.Result, (len(g.Moves)+1)/2)
+	for _, tag := range []string{"Event", "Date"} {
+		if g.Tags[tag] != "" {
+			s += ", " + g.Tags[tag]
+		}
+	}
+	return s
+}
+
+func printGames(games []DBGame) {
+	for _, g := range games {
+		fmt.Println(g)
+	}
+	fmt.Println(summarize(games))
+}
+
+const dbUsage = `usage: go_chess db [-file FILE] COMMAND
+
+Commands:
+  import FILE.pgn...   add the games of PGN files to the database
+  fen "FEN"            list the games where a position occurred
+  player NAME          list the games of a player
+  openings             show results grouped by opening
+`
+
+// dbMain runs the db subcommand and returns the exit status.
+func dbMain(args []string) int {
+	fs := flag.NewFlagSet("db", flag.ContinueOnError)
+	file := fs.String("file", getGameDBPath(), "database `file`")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), dbUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	db, err := loadGameDB(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "import":
+		// A file or game that cannot be read is reported and the rest are
+		// still imported; unreadable files make the exit status 1.
+		added, duplicates, unreadable, status := 0, 0, 0, 0
+		for _, path := range rest {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				status = 1
+				continue
+			}
+			games, skipped, err := readPGN(string(data))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				status = 1
+				continue
+			}
+			for _, err := range skipped {
+				fmt.Fprintf(os.Stderr, "%s: skipping %v\n", path, err)
+			}
+			unreadable += len(skipped)
+			for _, game := range games {
+				if db.add(game) {
+					added++
+				} else {
+					duplicates++
+				}
+			}
+		}
+		if err := saveGameDB(*file, db); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Imported %d games (%d duplicates and %d unreadable games skipped), %d games in %s\n",
+			added, duplicates, unreadable, len(db.Games), *file)
+		return status
+	case "fen":
+		game := NewChessGame()
+		if err := game.loadFEN(strings.Join(rest, " ")); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printGames(db.gamesWithPosition(game.zobrist()))
+	case "player":
+		if len(rest) == 0 {
+			fs.Usage()
+			return 2
+		}
+		printGames(db.gamesByPlayer(strings.Join(rest, " ")))
+	case "openings":
+		byOpening := map[string][]DBGame{}
+		for _, g := range db.Games {
+			byOpening[g.opening()] = append(byOpening[g.opening()], g)
+		}
+		names := make([]string, 0, len(byOpening))
+		for name := range byOpening {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if len(byOpening[names[i]]) != len(byOpening[names[j]]) {
+				return len(byOpening[names[i]]) > len(byOpening[names[j]])
+			}
+			return names[i] < names[j]
+		})
+		for _, name := range names {
+			fmt.Printf("%-40s %s\n", name, summarize(byOpening[name]))
+		}
+	default:
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Tablebase holds the distance to mate, in plies, for every position of
+// king and piece against king: White has a king and one queen, rook or
+// pawn, Black a bare king. A value of -1 means White cannot force mate,
+// either because the position is drawn or because it cannot occur.
+type Tablebase struct {
+	piece byte // 'Q', 'R' or 'P'
+	dtm   []int16
+}
+
+// endgames are the material sets the trainer can solve, by name.
+var endgames = map[string]byte{"KQvK": 'Q', "KRvK": 'R', "KPvK": 'P'}
+
+var kingSteps = [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+var rookDirs = [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+var queenDirs = [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+
+// tbIndex packs the white king, black king and white piece squares
+// (row*8+col) and the side to move into a table index.
+func tbIndex(wk, bk, x int, blackToMove bool) int {
+	i := (wk*64+bk)*64 + x
+	if blackToMove {
+		return i*2 + 1
+	}
+	return i * 2
+}
+
+func kingsTouch(a, b int) bool {
+	return abs(a/8-b/8) <= 1 && abs(a%8-b%8) <= 1
+}
+
+// pieceAttacks reports whether the white piece on x attacks target, with
+// blocker as the only other piece that can be in the way.
+func pieceAttacks(piece byte, x, target, blocker int) bool {
+	xr, xc, tr, tc := x/8, x%8, target/8, target%8
+	if piece == 'P' {
+		return tr == xr-1 && abs(tc-xc) == 1
+	}
+	dr, dc := tr-xr, tc-xc
+	straight := dr == 0 || dc == 0
+	diagonal := abs(dr) == abs(dc)
+	if x == target || !(straight || (piece == 'Q' && diagonal)) {
+		return false
+	}
+	sr, sc := sign(dr), sign(dc)
+	for r, c := xr+sr, xc+sc; r != tr || c != tc; r, c = r+sr, c+sc {
+		if r*8+c == blocker {
+			return false
+		}
+	}
+	return true
+}
+
+// legalPosition reports whether the pieces can stand like this with the
+// given side to move.
+func (tb *Tablebase) legalPosition(wk, bk, x int, blackToMove bool) bool {
+	if wk == bk || wk == x || bk == x || kingsTouch(wk, bk) {
+		return false
+	}
+	if tb.piece == 'P' && (x/8 == 0 || x/8 == 7) {
+		return false
+	}
+	return blackToMove || !pieceAttacks(tb.piece, x, bk, wk)
+}
+
+// blackMoves calls visit for every legal black king move. captures is set
+// when the king can take the white piece, which draws.
+func (tb *Tablebase) blackMoves(wk, bk, x int, visit func(to int)) (captures bool) {
+	for _, step := range kingSteps {
+		r, c := bk/8+step[0], bk%8+step[1]
+		if r < 0 || r > 7 || c < 0 || c > 7 {
+			continue
+		}
+		to := r*8 + c
+		if kingsTouch(to, wk) {
+			continue
+		}
+		if to == x {
+			captures = true
+			continue
+		}
+		if pieceAttacks(tb.piece, x, to, wk) {
+			continue
+		}
+		visit(to)
+	}
+	return captures
+}
+
+// whiteMoves calls visit for every legal white move with the new king and
+// piece squares; promoted is set when a pawn reaches the last rank.
+func (tb *Tablebase) whiteMoves(wk, bk, x int, visit func(wk, x int, promoted bool)) {
+	for _, step := range kingSteps {
+		r, c := wk/8+step[0], wk%8+step[1]
+		if r < 0 || r > 7 || c < 0 || c > 7 {
+			continue
+		}
+		if to := r*8 + c; to != x && !kingsTouch(to, bk) {
+			visit(to, x, false)
+		}
+	}
+
+	if tb.piece == 'P' {
+		one := x - 8
+		if one != wk && one != bk {
+			visit(wk, one, one/8 == 0)
+			if two := x - 16; x/8 == 6 && two != wk && two != bk {
+				visit(wk, two, false)
+			}
+		}
+		return
+	}
+
+	dirs := rookDirs
+	if tb.piece == 'Q' {
+		dirs = queenDirs
+	}
+	for _, d := range dirs {
+		for r, c := x/8+d[0], x%8+d[1]; r >= 0 && r < 8 && c >= 0 && c < 8; r, c = r+d[0], c+d[1] {
+			to := r*8 + c
+			if to == wk || to == bk {
+				break
+			}
+			visit(wk, to, false)
+		}
+	}
+}
+
+// solveEndgame computes the distance to mate for every position by working
+// back from the mates: White mates in n plies when some move reaches a
+// position where Black is mated in n-1, and Black is mated in n when every
+// move reaches a position White mates in at most n-1, one of them in
+// exactly n-1. promotion is the KQvK table that pawn promotions lead to.
+func solveEndgame(piece byte, promotion *Tablebase) *Tablebase {
+	tb := &Tablebase{piece: piece, dtm: make([]int16, 64*64*64*2)}
+	for i := range tb.dtm {
+		tb.dtm[i] = -1
+	}
+
+	for wk := 0; wk < 64; wk++ {
+		for bk := 0; bk < 64; bk++ {
+			for x := 0; x < 64; x++ {
+				if !tb.legalPosition(wk, bk, x, true) || !pieceAttacks(piece, x, bk, wk) {
+					continue
+				}
+				hasMove := false
+				captures := tb.blackMoves(wk, bk, x, func(int) { hasMove = true })
+				if !hasMove && !captures {
+					tb.dtm[tbIndex(wk, bk, x, true)] = 0
+				}
+			}
+		}
+	}
+
+	for n := int16(1); ; n++ {
+		changed := false
+		blackToMove := n%2 == 0
+		for wk := 0; wk < 64; wk++ {
+			for bk := 0; bk < 64; bk++ {
+				for x := 0; x < 64; x++ {
+					i := tbIndex(wk, bk, x, blackToMove)
+					if tb.dtm[i] != -1 || !tb.legalPosition(wk, bk, x, blackToMove) {
+						continue
+					}
+					if blackToMove && tb.blackLosesIn(wk, bk, x, n) || !blackToMove && tb.whiteMatesIn(wk, bk, x, n, promotion) {
+						tb.dtm[i] = n
+						changed = true
+					}
+				}
+			}
+		}
+		if !changed {
+			return tb
+		}
+	}
+}
+
+func (tb *Tablebase) whiteMatesIn(wk, bk, x int, n int16, promotion *Tablebase) bool {
+	found := false
+	tb.whiteMoves(wk, bk, x, func(toWK, toX int, promoted bool) {
+		if found {
+			return
+		}
+		if promoted {
+			found = promotion.dtm[tbIndex(toWK, bk, toX, true)] == n-1
+		} else {
+			found = tb.dtm[tbIndex(toWK, bk, toX, true)] == n-1
+		}
+	})
+	return found
+}
+
+func (tb *Tablebase) blackLosesIn(wk, bk, x int, n int16) bool {
+	longest, escapes := int16(-1), false
+	captures := tb.blackMoves(wk, bk, x, func(to int) {
+		d := tb.dtm[tbIndex(wk, to, x, false)]
+		if d == -1 {
+			escapes = true
+		} else if d > longest {
+			longest = d
+		}
+	})
+	return !captures && !escapes && longest == n-1
+}
+
+// EndgameTrainer probes the solved tables for positions on a ChessGame
+// board.
+type EndgameTrainer struct {
+	name   string
+	tables map[byte]*Tablebase
+}
+
+func newEndgameTrainer(name string) (*EndgameTrainer, error) {
+	piece, ok := endgames[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown endgame %q, choose KQvK, KRvK or KPvK", name)
+	}
+	t := &EndgameTrainer{name: name, tables: map[byte]*Tablebase{}}
+	// Only a pawn can turn into a queen, so KQvK is solved for KPvK
+	// promotions and otherwise just for its own sake.
+	var promotion *Tablebase
+	if piece == 'P' {
+		promotion = solveEndgame('Q', nil)
+		t.tables['Q'] = promotion
+	}
+	t.tables[piece] = solveEndgame(piece, promotion)
+	return t, nil
+}
+
+// probe returns the distance to mate in plies for a board, and false when
+// White cannot force mate or the material is not one the trainer solved.
+func (t *EndgameTrainer) probe(board [boardSize][boardSize]string, whiteToMove bool) (int, bool) {
+	wk, bk, x := -1, -1, -1
+	var piece byte
+	for row := 0; row < boardSize; row++ {
+		for col := 0; col < boardSize; col++ {
+			sq := row*8 + col
+			switch p := board[row][col]; p {
+			case "":
+			case "K":
+				wk = sq
+			case "k":
+				bk = sq
+			case "Q", "R", "P":
+				if x != -1 {
+					return 0, false
+				}
+				x, piece = sq, p[0]
+			default:
+				return 0, false
+			}
+		}
+	}
+	tb, ok := t.tables[piece]
+	if !ok || wk == -1 || bk == -1 {
+		return 0, false
+	}
+	d := tb.dtm[tbIndex(wk, bk, x, !whiteToMove)]
+	return int(d), d >= 0
+}
+
+// probeMove is probe for the position after move.
+func (t *EndgameTrainer) probeMove(game *ChessGame, m Move) (int, bool) {
+	next := &ChessGame{board: game.board, whiteToMove: game.whiteToMove}
+	next.applyMove(m)
+	return t.probe(next.board, next.whiteToMove)
+}
+
+// bestMove picks the fastest mate for White, and for Black the move that
+// holds out longest, preferring any move that escapes the loss.
+func (t *EndgameTrainer) bestMove(game *ChessGame) (Move, bool) {
+	var best Move
+	bestDTM, found := 0, false
+	for _, m := range game.legalMoves() {
+		d, win := t.probeMove(game, m)
+		if game.whiteToMove {
+			if win && (!found || d < bestDTM) {
+				best, bestDTM, found = m, d, true
+			}
+			continue
+		}
+		if !win {
+			return m, true
+		}
+		if !found || d > bestDTM {
+			best, bestDTM, found = m, d, true
+		}
+	}
+	return best, found
+}
+
+// randomPosition sets up a won position that takes at least minPlies to
+// mate with best play, or the longest one found.
+func (t *EndgameTrainer) randomPosition(rng *rand.Rand, minPlies int) *ChessGame {
+	tb := t.tables[endgames[t.name]]
+	var best [3]int
+	bestDTM := -1
+	for tries := 0; tries < 10000 && bestDTM < minPlies; tries++ {
+		wk, bk, x := rng.Intn(64), rng.Intn(64), rng.Intn(64)
+		if d := int(tb.dtm[tbIndex(wk, bk, x, false)]); d > bestDTM {
+			best, bestDTM = [3]int{wk, bk, x}, d
+		}
+	}
+
+	var board [boardSize][boardSize]string
+	board[best[0]/8][best[0]%8] = "K"
+	board[best[1]/8][best[1]%8] = "k"
+	board[best[2]/8][best[2]%8] = string(tb.piece)
+	game := NewChessGame()
+	game.loadFEN(positionFEN(board, true, "", "", 1))
+	return game
+}
+
+func movesFromPlies(plies int) int {
+	return (plies + 1) / 2
+}
+
+func runEndgameTrainer(name string, scanner *bufio.Scanner) error {
+	fmt.Printf("Solving %s...\n", name)
+	trainer, err := newEndgameTrainer(name)
+	if err != nil {
+		return err
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for {
+		if quit := trainer.play(trainer.randomPosition(rng, 20), scanner); quit {
+			return nil
+		}
+	}
+}
+
+// play runs one training game with the player as White and reports whether
+// the player asked to quit.
+func (t *EndgameTrainer) play(game *ChessGame, scanner *bufio.Scanner) bool {
+	start, _ := t.probe(game.board, true)
+	played := 0
+	status := fmt.Sprintf("Mate the black king. With best play it takes %d moves.", movesFromPlies(start))
+
+	for !game.isOver() {
+		clearTerminal()
+		game.printBoard()
+		fmt.Printf("\nEndgame trainer: %s\n%s\n", t.name, status)
+		fmt.Print("Your move (e.g., Qd5 or d1d5), 'hint', 'new' or 'quit': ")
+		if !scanner.Scan() {
+			return true
+		}
+		input := strings.TrimSpace(scanner.Text())
+
+		switch strings.ToLower(input) {
+		case "quit":
+			return true
+		case "new":
+			return false
+		case "hint":
+			if m, ok := t.bestMove(game); ok {
+				status = fmt.Sprintf("Hint: %s", m.san)
+			} else {
+				status = "There is no win left to find."
+			}
+			continue
+		}
+
+		from, to, err := game.resolveMove(input)
+		if err != nil {
+			status = fmt.Sprintf("%s: %v", input, err)
+			continue
+		}
+		before, wasWin := t.probe(game.board, true)
+		game.movePiece(from, to)
+		played++
+		if game.isOver() {
+			break
+		}
+
+		after, win := t.probe(game.board, false)
+		switch {
+		case !wasWin:
+			status = "The win was already gone."
+		case !win:
+			status = fmt.Sprintf("%s lets Black escape: the win is gone.", game.current.move.san)
+		case after == before-1:
+			status = fmt.Sprintf("%s is best. Mate in %d.", game.current.move.san, movesFromPlies(after))
+		default:
+			status = fmt.Sprintf("%s keeps the win, but mate is now %d moves away instead of %d.",
+				game.current.move.san, movesFromPlies(after), movesFromPlies(before-1))
+		}
+
+		reply, ok := t.bestMove(game)
+		if !ok {
+			break
+		}
+		game.movePiece(squareName(reply.fromRow, reply.fromCol), squareName(reply.toRow, reply.toCol))
+		status += fmt.Sprintf(" Black replied %s.", reply.san)
+		if !game.isOver() && !t.hasWhitePiece(game) {
+			status = fmt.Sprintf("Black took your last piece with %s. It's a draw.", reply.san)
+			break
+		}
+	}
+
+	clearTerminal()
+	game.printBoard()
+	fmt.Println()
+	if game.result == WhiteWins {
+		fmt.Printf("Checkmate in %d moves. Perfect play needed %d.\n", played, movesFromPlies(start))
+	} else if game.isOver() {
+		fmt.Printf("%s.\n", game.resultText())
+	} else {
+		fmt.Println(status)
+	}
+	fmt.Print("Press ENTER for a new position, or type 'quit': ")
+	if !scanner.Scan() {
+		return true
+	}
+	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "quit"
+}
+
+func (t *EndgameTrainer) hasWhitePiece(game *ChessGame) bool {
+	for row := 0; row < boardSize; row++ {
+		for col := 0; col < boardSize; col++ {
+			if p := game.board[row][col]; p != "" && p != "K" && isWhitePiece(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// trainers caches the solved endgames, which take about a second each.
+var trainers = map[string]*EndgameTrainer{}
+
+func trainer(t *testing.T, name string) *EndgameTrainer {
+	t.Helper()
+	if tr, ok := trainers[name]; ok {
+		return tr
+	}
+	tr, err := newEndgameTrainer(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainers[name] = tr
+	return tr
+}
+
+func TestSolveEndgameLongestMate(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantMoves int
+	}{
+		{"KQvK", 10},
+		{"KRvK", 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := trainer(t, tt.name).tables[endgames[tt.name]]
+			longest := -1
+			for wk := 0; wk < 64; wk++ {
+				for bk := 0; bk < 64; bk++ {
+					for x := 0; x < 64; x++ {
+						longest = max(longest, int(tb.dtm[tbIndex(wk, bk, x, false)]))
+					}
+				}
+			}
+			if got := movesFromPlies(longest); got != tt.wantMoves {
+				t.Errorf("longest mate in %d moves, want %d", got, tt.wantMoves)
+			}
+		})
+	}
+}
+
+func TestEndgameProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		endgame string
+		fen     string
+		want    int
+		wantWin bool
+	}{
+		{"mate in one", "KQvK", "k7/8/1K6/8/8/8/7Q/8 w - - 0 1", 1, true},
+		{"mated", "KRvK", "R1k5/8/2K5/8/8/8/8/8 b - - 0 1", 0, true},
+		{"queen hangs", "KQvK", "8/8/8/3k4/3Q4/8/8/K7 b - - 0 1", 0, false},
+		{"pawn promotes", "KPvK", "8/4P3/8/8/8/8/k7/4K3 w - - 0 1", 13, true},
+		{"wrong rook pawn", "KPvK", "k7/8/8/8/8/8/P7/K7 w - - 0 1", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewChessGame()
+			if err := game.loadFEN(tt.fen); err != nil {
+				t.Fatal(err)
+			}
+			got, win := trainer(t, tt.endgame).probe(game.board, game.whiteToMove)
+			if win != tt.wantWin || win && got != tt.want {
+				t.Errorf("probe = %d, %v; want %d, %v", got, win, tt.want, tt.wantWin)
+			}
+		})
+	}
+}
+
+func TestEndgameBestPlay(t *testing.T) {
+	tr := trainer(t, "KQvK")
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 5; i++ {
+		game := tr.randomPosition(rng, 15)
+		start, win := tr.probe(game.board, game.whiteToMove)
+		if !win {
+			t.Fatalf("%s: not a win", game.fen())
+		}
+		fen, plies := game.fen(), 0
+		for !game.isOver() {
+			m, ok := tr.bestMove(game)
+			if !ok {
+				t.Fatalf("%s: no move after %d plies", fen, plies)
+			}
+			if err := game.movePiece(squareName(m.fromRow, m.fromCol), squareName(m.toRow, m.toCol)); err != nil {
+				t.Fatal(err)
+			}
+			plies++
+		}
+		if game.result != WhiteWins || plies != start {
+			t.Errorf("%s: %s after %d plies, want mate in %d", fen, game.result, plies, start)
+		}
+	}
+}
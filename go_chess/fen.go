@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// loadFEN replaces the current position with the one described by fen.
+// The move counters are ignored. Without a castling field neither side may
+// castle.
+func (c *ChessGame) loadFEN(fen string) error {
+	fields := strings.Fields(fen)
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid FEN %q: need piece placement and side to move", fen)
+	}
+
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != boardSize {
+		return fmt.Errorf("invalid FEN %q: expected %d ranks", fen, boardSize)
+	}
+
+	var board [boardSize][boardSize]string
+	for row, rank := range ranks {
+		col := 0
+		for _, ch := range rank {
+			switch {
+			case ch >= '1' && ch <= '8':
+				col += int(ch - '0')
+			case strings.ContainsRune("pnbrqkPNBRQK", ch):
+				if col >= boardSize {
+					return fmt.Errorf("invalid FEN %q: rank %d is too long", fen, 8-row)
+				}
+				board[row][col] = string(ch)
+				col++
+			default:
+				return fmt.Errorf("invalid FEN %q: unexpected %q", fen, ch)
+			}
+		}
+		if col != boardSize {
+			return fmt.Errorf("invalid FEN %q: rank %d has %d squares", fen, 8-row, col)
+		}
+	}
+
+	switch fields[1] {
+	case "w":
+		c.whiteToMove = true
+	case "b":
+		c.whiteToMove = false
+	default:
+		return fmt.Errorf("invalid FEN %q: side to move must be w or b", fen)
+	}
+
+	castling, enPassant := "", ""
+	if len(fields) > 2 && fields[2] != "-" {
+		castling = fields[2]
+		if strings.Trim(castling, "KQkq") != "" {
+			return fmt.Errorf("invalid FEN %q: castling rights must be - or letters of KQkq", fen)
+		}
+	}
+	if len(fields) > 3 && fields[3] != "-" {
+		enPassant = fields[3]
+		if row, _ := parsePosition(enPassant); row != 2 && row != 5 {
+			return fmt.Errorf("invalid FEN %q: bad en passant square %q", fen, enPassant)
+		}
+	}
+
+	c.board = board
+	c.castling = castling
+	c.enPassant = enPassant
+	c.newTree()
+	return nil
+}
+
+// fen describes the current position in Forsyth-Edwards Notation.
+func (c *ChessGame) fen() string {
+	plies := c.current.ply
+	if !c.startWhiteToMove {
+		plies++
+	}
+	return positionFEN(c.board, c.whiteToMove, c.castling, c.enPassant, plies/2+1)
+}
+
+func positionFEN(board [boardSize][boardSize]string, whiteToMove bool, castling, enPassant string, fullmove int) string {
+	var sb strings.Builder
+	for row := 0; row < boardSize; row++ {
+		empty := 0
+		for col := 0; col < boardSize; col++ {
+			piece := board[row][col]
+			if piece == "" {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				fmt.Fprintf(&sb, "%d", empty)
+				empty = 0
+			}
+			sb.WriteString(piece)
+		}
+		if empty > 0 {
+			fmt.Fprintf(&sb, "%d", empty)
+		}
+		if row < boardSize-1 {
+			sb.WriteByte('/')
+		}
+	}
+
+	side := "w"
+	if !whiteToMove {
+		side = "b"
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	if enPassant == "" {
+		enPassant = "-"
+	}
+	fmt.Fprintf(&sb, " %s %s %s 0 %d", side, castling, enPassant, fullmove)
+	return sb.String()
+}
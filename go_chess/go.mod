@@ -1,3 +1,3 @@
-module main
+module go_chess
 
 go 1.24.1
@@ -2,8 +2,12 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -11,13 +15,36 @@ const boardSize = 8
 
 type Move struct {
 	fromRow, fromCol, toRow, toCol int
+	piece                          string
 	capturedPiece                  string
+	promotion                      string // piece a pawn turns into on the last rank
+	enPassant                      bool   // capturedPiece stood beside the pawn, not on the to square
+	prevCastling, prevEnPassant    string // the position's castling rights and en passant square before the move
+	san                            string
+	nag                            string // e.g. "!?", or "$14" for NAGs without a symbol
+	comment                        string
 }
 
 type ChessGame struct {
 	board       [boardSize][boardSize]string
-	moveHistory []Move
-	redoStack   []Move
+	whiteToMove bool
+	castling    string // castling rights as in FEN, e.g. "KQkq", "" for none
+	enPassant   string // square a pawn that just moved two squares passed over, e.g. "e3"
+
+	startBoard       [boardSize][boardSize]string
+	startWhiteToMove bool
+	startCastling    string
+	startEnPassant   string
+	root             *GameNode
+	current          *GameNode
+	nodeCount        int
+
+	result      string
+	termination Termination
+	drawOffer   string // side that offered a draw, if any
+	tags        map[string]string
+
+	db *GameDB // game database for position statistics, if any
 }
 
 func NewChessGame() *ChessGame {
@@ -28,62 +55,113 @@ func NewChessGame() *ChessGame {
 
 func (c *ChessGame) setupBoard() {
 	c.board = [boardSize][boardSize]string{
-		{"R", "N", "B", "Q", "K", "B", "N", "R"},
-		{"P", "P", "P", "P", "P", "P", "P", "P"},
+		{"r", "n", "b", "q", "k", "b", "n", "r"},
+		{"p", "p", "p", "p", "p", "p", "p", "p"},
 		{"", "", "", "", "", "", "", ""},
 		{"", "", "", "", "", "", "", ""},
 		{"", "", "", "", "", "", "", ""},
 		{"", "", "", "", "", "", "", ""},
-		{"p", "p", "p", "p", "p", "p", "p", "p"},
-		{"r", "n", "b", "q", "k", "b", "n", "r"},
+		{"P", "P", "P", "P", "P", "P", "P", "P"},
+		{"R", "N", "B", "Q", "K", "B", "N", "R"},
 	}
+	c.whiteToMove = true
+	c.castling = "KQkq"
+	c.enPassant = ""
+	c.newTree()
 }
 
 var boardColors = [2]string{"⬜", "⬛"} // White and black squares
+// Uppercase is white and lowercase is black, as in FEN.
 var unicodePieces = map[string]string{
-	"R": "♜", "N": "♞", "B": "♝", "Q": "♛", "K": "♚", "P": "♟",
-	"r": "♖", "n": "♘", "b": "♗", "q": "♕", "k": "♔", "p": "♙",
+	"R": "♖", "N": "♘", "B": "♗", "Q": "♕", "K": "♔", "P": "♙",
+	"r": "♜", "n": "♞", "b": "♝", "q": "♛", "k": "♚", "p": "♟",
 }
 
 func (c *ChessGame) printBoard() {
-	fmt.Println("x   a   b   c   d   e   f   g   h  x \n")
+	lines := []string{"x   a   b   c   d   e   f   g   h  x ", ""}
 	for i := 0; i < boardSize; i++ {
-		fmt.Printf("%d ", 8-i)
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d ", 8-i)
 		for j := 0; j < boardSize; j++ {
 			piece := c.board[i][j]
 			color := boardColors[(i+j)%2]
 			if piece == "" {
-				fmt.Printf("%s  ", color)
+				fmt.Fprintf(&sb, "%s  ", color)
 			} else {
-				fmt.Printf("%s%s ", color, unicodePieces[piece])
+				fmt.Fprintf(&sb, "%s%s ", color, unicodePieces[piece])
 			}
 		}
-		fmt.Printf(" %d\n\n", 8-i)
+		fmt.Fprintf(&sb, " %d", 8-i)
+		lines = append(lines, sb.String(), "")
+	}
+	lines = append(lines, "x   a   b   c   d   e   f   g   h  x")
+
+	width := displayWidth(lines[0])
+	panel := c.sidePanel(len(lines))
+	for i, line := range lines {
+		if panel[i] == "" {
+			fmt.Println(line)
+			continue
+		}
+		fmt.Printf("%s%s   %s\n", line, strings.Repeat(" ", width-displayWidth(line)), panel[i])
 	}
-	fmt.Println("x   a   b   c   d   e   f   g   h  x")
 }
 
-func (c *ChessGame) movePiece(from, to string) bool {
+// movePiece plays a move given by its from and to squares, or says why it
+// cannot be played.
+func (c *ChessGame) movePiece(from, to string) error {
 	fromRow, fromCol := parsePosition(from)
 	toRow, toCol := parsePosition(to)
 
 	if fromRow == -1 || toRow == -1 {
-		fmt.Println("Invalid move!")
-		return false
+		return fmt.Errorf("invalid square")
 	}
 
-	if !c.isValidMove(fromRow, fromCol, toRow, toCol) {
-		fmt.Println("Illegal move!")
-		return false
+	if c.isOver() {
+		return fmt.Errorf("the game is over")
 	}
 
-	move := Move{fromRow, fromCol, toRow, toCol, c.board[toRow][toCol]}
-	c.moveHistory = append(c.moveHistory, move)
-	c.redoStack = nil // Clear redo stack on new move
+	if !c.isLegalMove(fromRow, fromCol, toRow, toCol) {
+		return fmt.Errorf("illegal move")
+	}
 
-	c.board[toRow][toCol] = c.board[fromRow][fromCol]
-	c.board[fromRow][fromCol] = ""
-	return true
+	if isWhitePiece(c.board[fromRow][fromCol]) != c.whiteToMove {
+		return fmt.Errorf("it is %s's turn", sideName(c.whiteToMove))
+	}
+
+	c.playMove(fromRow, fromCol, toRow, toCol)
+	if c.drawOffer == sideName(c.whiteToMove) {
+		c.drawOffer = "" // Replying with a move declines the offer
+	}
+	c.checkGameEnd()
+	return nil
+}
+
+// playMove records and plays a move that has already been checked.
+func (c *ChessGame) playMove(fromRow, fromCol, toRow, toCol int) *GameNode {
+	move := c.newMove(fromRow, fromCol, toRow, toCol)
+	move.san = c.moveSAN(fromRow, fromCol, toRow, toCol)
+	node := c.addMove(move)
+	c.applyMove(move)
+	return node
+}
+
+// newMove describes a move on the current board before it is played,
+// with what is needed to take it back.
+func (c *ChessGame) newMove(fromRow, fromCol, toRow, toCol int) Move {
+	move := Move{
+		fromRow: fromRow, fromCol: fromCol, toRow: toRow, toCol: toCol,
+		piece:         c.board[fromRow][fromCol],
+		capturedPiece: c.board[toRow][toCol],
+		promotion:     promotionPiece(c.board[fromRow][fromCol], toRow),
+		prevCastling:  c.castling,
+		prevEnPassant: c.enPassant,
+	}
+	if c.isEnPassant(fromRow, fromCol, toRow, toCol) {
+		move.enPassant = true
+		move.capturedPiece = c.board[fromRow][toCol]
+	}
+	return move
 }
 
 func (c *ChessGame) isValidMove(fromRow, fromCol, toRow, toCol int) bool {
@@ -127,7 +205,14 @@ func isValidPawnMove(fromRow, fromCol, toRow, toCol int, piece string, board [bo
 		direction = 1
 	}
 	if fromCol == toCol && board[toRow][toCol] == "" {
-		return toRow == fromRow+direction || (fromRow == 1 && toRow == fromRow+2 && piece == "P") || (fromRow == 6 && toRow == fromRow-2 && piece == "p")
+		if toRow == fromRow+direction {
+			return true
+		}
+		startRow := 6
+		if piece == "p" {
+			startRow = 1
+		}
+		return fromRow == startRow && toRow == fromRow+2*direction && board[fromRow+direction][fromCol] == ""
 	}
 	if abs(fromCol-toCol) == 1 && toRow == fromRow+direction && board[toRow][toCol] != "" {
 		return true // Capturing diagonally
@@ -135,6 +220,114 @@ func isValidPawnMove(fromRow, fromCol, toRow, toCol int, piece string, board [bo
 	return false
 }
 
+// isEnPassant reports whether a pawn move is the capture of a pawn that has
+// just moved two squares past it, onto the square it passed over.
+func (c *ChessGame) isEnPassant(fromRow, fromCol, toRow, toCol int) bool {
+	piece := c.board[fromRow][fromCol]
+	if c.enPassant == "" || squareName(toRow, toCol) != c.enPassant || abs(fromCol-toCol) != 1 {
+		return false
+	}
+	return piece == "P" && toRow == 2 && fromRow == 3 || piece == "p" && toRow == 5 && fromRow == 4
+}
+
+// isCastling reports whether a move is a king moving two squares, which
+// only castling does.
+func isCastling(move Move) bool {
+	return strings.ToUpper(move.piece) == "K" && abs(move.toCol-move.fromCol) == 2
+}
+
+// castlingRook returns the columns the rook moves from and to when the king
+// castles onto toCol.
+func castlingRook(toCol int) (int, int) {
+	if toCol > 4 {
+		return 7, 5
+	}
+	return 0, 3
+}
+
+// canCastle reports whether the king may castle onto toCol: it and the rook
+// have not moved, the squares between them are empty, and the king is not
+// in check and does not pass through an attacked square. Whether it lands
+// in check is left to isLegalMove.
+func (c *ChessGame) canCastle(fromRow, fromCol, toRow, toCol int) bool {
+	king := c.board[fromRow][fromCol]
+	white := isWhitePiece(king)
+	homeRow := 0
+	if white {
+		homeRow = boardSize - 1
+	}
+	if strings.ToUpper(king) != "K" || fromRow != homeRow || toRow != homeRow || fromCol != 4 || abs(toCol-fromCol) != 2 {
+		return false
+	}
+	rookCol, _ := castlingRook(toCol)
+	right, rook := "K", "R"
+	if rookCol == 0 {
+		right = "Q"
+	}
+	if !white {
+		right, rook = strings.ToLower(right), "r"
+	}
+	if !strings.Contains(c.castling, right) || c.board[homeRow][rookCol] != rook {
+		return false
+	}
+	for col := min(fromCol, rookCol) + 1; col < max(fromCol, rookCol); col++ {
+		if c.board[homeRow][col] != "" {
+			return false
+		}
+	}
+	if c.isInCheck(white) {
+		return false
+	}
+	passed := (fromCol + toCol) / 2
+	c.board[homeRow][passed], c.board[homeRow][fromCol] = king, ""
+	attacked := c.isInCheck(white)
+	c.board[homeRow][fromCol], c.board[homeRow][passed] = king, ""
+	return !attacked
+}
+
+// castlingRights returns the rights left after a move. A king move gives up
+// both of its side's rights, and a move from or onto a corner gives up the
+// right of the rook that started there.
+func castlingRights(rights string, move Move) string {
+	lost := ""
+	switch move.piece {
+	case "K":
+		lost = "KQ"
+	case "k":
+		lost = "kq"
+	}
+	for _, sq := range []string{squareName(move.fromRow, move.fromCol), squareName(move.toRow, move.toCol)} {
+		switch sq {
+		case "h1":
+			lost += "K"
+		case "a1":
+			lost += "Q"
+		case "h8":
+			lost += "k"
+		case "a8":
+			lost += "q"
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(lost, r) {
+			return -1
+		}
+		return r
+	}, rights)
+}
+
+// promotionPiece returns the queen a pawn becomes when it reaches the last
+// rank, or "" for any other move. Underpromotion is not supported.
+func promotionPiece(piece string, toRow int) string {
+	switch {
+	case piece == "P" && toRow == 0:
+		return "Q"
+	case piece == "p" && toRow == boardSize-1:
+		return "q"
+	}
+	return ""
+}
+
 func isValidKnightMove(fromRow, fromCol, toRow, toCol int) bool {
 	dr, dc := abs(fromRow-toRow), abs(fromCol-toCol)
 	return (dr == 2 && dc == 1) || (dr == 1 && dc == 2)
@@ -164,6 +357,94 @@ func isPathClear(fromRow, fromCol, toRow, toCol int, board [boardSize][boardSize
 	return true
 }
 
+// isLegalMove is isValidMove plus castling and en passant, and the rule
+// that a move may not leave the mover's own king in check.
+func (c *ChessGame) isLegalMove(fromRow, fromCol, toRow, toCol int) bool {
+	if !c.isValidMove(fromRow, fromCol, toRow, toCol) && !c.isEnPassant(fromRow, fromCol, toRow, toCol) &&
+		!c.canCastle(fromRow, fromCol, toRow, toCol) {
+		return false
+	}
+	move := c.newMove(fromRow, fromCol, toRow, toCol)
+	c.applyMove(move)
+	inCheck := c.isInCheck(isWhitePiece(move.piece))
+	c.unapplyMove(move)
+	return !inCheck
+}
+
+// isInCheck reports whether the king of the given color is attacked.
+func (c *ChessGame) isInCheck(white bool) bool {
+	king := "k"
+	if white {
+		king = "K"
+	}
+	for row := 0; row < boardSize; row++ {
+		for col := 0; col < boardSize; col++ {
+			if c.board[row][col] == king {
+				return c.isAttackedBy(row, col, !white)
+			}
+		}
+	}
+	return false
+}
+
+// isAttackedBy reports whether a piece of the given color could capture on
+// the occupied square row, col.
+func (c *ChessGame) isAttackedBy(row, col int, white bool) bool {
+	for r := 0; r < boardSize; r++ {
+		for f := 0; f < boardSize; f++ {
+			piece := c.board[r][f]
+			if piece != "" && isWhitePiece(piece) == white && c.isValidMove(r, f, row, col) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasLegalMove reports whether the given color has any legal move.
+func (c *ChessGame) hasLegalMove(white bool) bool {
+	for fromRow := 0; fromRow < boardSize; fromRow++ {
+		for fromCol := 0; fromCol < boardSize; fromCol++ {
+			piece := c.board[fromRow][fromCol]
+			if piece == "" || isWhitePiece(piece) != white {
+				continue
+			}
+			for toRow := 0; toRow < boardSize; toRow++ {
+				for toCol := 0; toCol < boardSize; toCol++ {
+					if c.isLegalMove(fromRow, fromCol, toRow, toCol) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// legalMoves lists the legal moves of the side to move, with their SAN.
+func (c *ChessGame) legalMoves() []Move {
+	var moves []Move
+	for fromRow := 0; fromRow < boardSize; fromRow++ {
+		for fromCol := 0; fromCol < boardSize; fromCol++ {
+			piece := c.board[fromRow][fromCol]
+			if piece == "" || isWhitePiece(piece) != c.whiteToMove {
+				continue
+			}
+			for toRow := 0; toRow < boardSize; toRow++ {
+				for toCol := 0; toCol < boardSize; toCol++ {
+					if !c.isLegalMove(fromRow, fromCol, toRow, toCol) {
+						continue
+					}
+					move := c.newMove(fromRow, fromCol, toRow, toCol)
+					move.san = c.moveSAN(fromRow, fromCol, toRow, toCol)
+					moves = append(moves, move)
+				}
+			}
+		}
+	}
+	return moves
+}
+
 func sign(n int) int {
 	if n > 0 {
 		return 1
@@ -181,7 +462,11 @@ func abs(n int) int {
 }
 
 func sameColor(a, b string) bool {
-	return (a >= "A" && a <= "Z") == (b >= "A" && b <= "Z")
+	return isWhitePiece(a) == isWhitePiece(b)
+}
+
+func isWhitePiece(piece string) bool {
+	return piece >= "A" && piece <= "Z"
 }
 
 func parsePosition(pos string) (int, int) {
@@ -196,32 +481,42 @@ func parsePosition(pos string) (int, int) {
 	return row, col
 }
 
-func (c *ChessGame) undoMove() {
-	if len(c.moveHistory) == 0 {
-		fmt.Println("No moves to undo!")
-		return
+// parseMoveInput splits input such as "e2 e4", "e2-e4" or "e2e4" into its
+// from and to squares.
+func parseMoveInput(input string) (string, string, bool) {
+	input = strings.NewReplacer(" ", "", "-", "").Replace(strings.ToLower(input))
+	if len(input) != 4 {
+		return "", "", false
 	}
+	return input[:2], input[2:], true
+}
 
-	lastMove := c.moveHistory[len(c.moveHistory)-1]
-	c.moveHistory = c.moveHistory[:len(c.moveHistory)-1]
+func (c *ChessGame) undoMove() error {
+	if c.current.parent == nil {
+		return fmt.Errorf("no moves to undo")
+	}
 
-	c.redoStack = append(c.redoStack, lastMove)
-	c.board[lastMove.fromRow][lastMove.fromCol] = c.board[lastMove.toRow][lastMove.toCol]
-	c.board[lastMove.toRow][lastMove.toCol] = lastMove.capturedPiece
+	node := c.current
+	c.current = node.parent
+	c.current.selected = node
+	c.unapplyMove(node.move)
+	c.updateResult()
+	return nil
 }
 
-func (c *ChessGame) redoMove() {
-	if len(c.redoStack) == 0 {
-		fmt.Println("No moves to redo!")
-		return
+func (c *ChessGame) redoMove() error {
+	next := c.current.selected
+	if next == nil && len(c.current.children) > 0 {
+		next = c.current.children[0]
+	}
+	if next == nil {
+		return fmt.Errorf("no moves to redo")
 	}
 
-	move := c.redoStack[len(c.redoStack)-1]
-	c.redoStack = c.redoStack[:len(c.redoStack)-1]
-	c.moveHistory = append(c.moveHistory, move)
-
-	c.board[move.toRow][move.toCol] = c.board[move.fromRow][move.fromCol]
-	c.board[move.fromRow][move.fromCol] = ""
+	c.current = next
+	c.applyMove(next.move)
+	c.updateResult()
+	return nil
 }
 
 func clearTerminal() {
@@ -229,32 +524,172 @@ func clearTerminal() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "db" {
+		os.Exit(dbMain(os.Args[2:]))
+	}
+
+	puzzleFile := flag.String("puzzles", "", "play the tactics puzzles in `file` (lines of id;FEN;moves[;rating])")
+	batchFile := flag.String("batch", "", "play the moves in `file` (- for stdin) without a board and print the outcome as JSON")
+	endgame := flag.String("endgame", "", "practise mating with `material` KQvK, KRvK or KPvK against perfect defence")
+	serveAddr := flag.String("serve", "", "serve the JSON API on `addr` (e.g. :8080) instead of playing")
+	flag.Parse()
+
+	if *serveAddr != "" {
+		log.Printf("Serving the go_chess API on %s", *serveAddr)
+		log.Fatal(http.ListenAndServe(*serveAddr, NewServer().Handler()))
+	}
+
+	if *batchFile != "" {
+		os.Exit(batchMain(*batchFile))
+	}
+
 	game := NewChessGame()
 	scanner := bufio.NewScanner(os.Stdin)
 
+	if *endgame != "" {
+		if err := runEndgameTrainer(*endgame, scanner); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *puzzleFile != "" {
+		puzzles, err := loadPuzzles(*puzzleFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		runPuzzles(puzzles, scanner)
+		return
+	}
+
+	db, err := loadGameDB(getGameDBPath())
+	if err == nil && len(db.Games) > 0 {
+		game.db = db
+	}
+
+	notice := ""
 	for {
 		clearTerminal()
 		game.printBoard()
-		fmt.Print("Enter move (e.g., e2-e4, or 'undo', or 'quit'): ")
-		scanner.Scan()
-		input := scanner.Text()
-
-		if strings.ToLower(input) == "quit" {
+		if notice != "" {
+			fmt.Println(notice)
+			notice = ""
+		}
+		fmt.Print("Enter move (e.g., e2-e4), 'undo', 'redo', 'variations', 'help' or 'quit': ")
+		if !scanner.Scan() {
 			break
-		} else if strings.ToLower(input) == "undo" {
-			game.undoMove()
-		} else if strings.ToLower(input) == "redo" {
-			game.redoMove()
-		} else {
-			moveParts := strings.Split(input, " ")
-			if len(moveParts) != 2 {
-				fmt.Println("Invalid move format. Use 'from to' (e.g., e2 e4).")
-				continue
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		arg := strings.Join(fields[1:], " ")
+
+		switch strings.ToLower(fields[0]) {
+		case "quit":
+			return
+		case "undo":
+			if err := game.undoMove(); err != nil {
+				notice = fmt.Sprintf("Cannot undo: %v", err)
 			}
-			from, to := moveParts[0], moveParts[1]
-			if !game.movePiece(from, to) {
-				continue
+		case "redo":
+			if err := game.redoMove(); err != nil {
+				notice = fmt.Sprintf("Cannot redo: %v", err)
+			}
+		case "variations":
+			notice = game.variationsText()
+		case "branch":
+			n, err := strconv.Atoi(arg)
+			if err == nil {
+				err = game.branch(n)
+			}
+			if err != nil {
+				notice = "Usage: branch N, where N is listed by 'variations'."
+			}
+		case "promote":
+			if err := game.promote(); err != nil {
+				notice = fmt.Sprintf("Cannot promote: %v", err)
+			} else {
+				notice = "The current line is now the main line."
+			}
+		case "goto":
+			id, err := strconv.Atoi(arg)
+			if err == nil {
+				err = game.jumpTo(id)
+			}
+			if err != nil {
+				notice = "Usage: goto ID, where ID is a node listed by 'variations' (0 is the start)."
+			}
+		case "export":
+			if arg == "" {
+				notice = "Usage: export FILE.pgn"
+			} else if err := game.exportPGN(arg); err != nil {
+				notice = fmt.Sprintf("Could not export: %v", err)
+			} else {
+				notice = "Game saved to " + arg
+			}
+		case "note":
+			game.comment(arg)
+		case "nag":
+			if err := game.annotate(arg); err != nil {
+				notice = fmt.Sprintf("Cannot annotate: %v", err)
+			}
+		case "load":
+			loaded, err := importPGN(arg)
+			if err != nil {
+				notice = fmt.Sprintf("Could not load: %v", err)
+			} else {
+				loaded.db = game.db
+				game = loaded
+				notice = "Game loaded from " + arg
+			}
+		case "resign":
+			if err := game.resign(); err != nil {
+				notice = fmt.Sprintf("Cannot resign: %v", err)
+			}
+		case "draw":
+			if err := game.offerDraw(); err != nil {
+				notice = fmt.Sprintf("Cannot offer a draw: %v", err)
+			} else {
+				notice = fmt.Sprintf("%s offers a draw. After %s's move, %s can type 'accept' or 'decline', or just play a move.", game.drawOffer, game.drawOffer, sideName(!game.whiteToMove))
+			}
+		case "accept", "decline":
+			if err := game.answerDraw(strings.ToLower(fields[0]) == "accept"); err != nil {
+				notice = fmt.Sprintf("Cannot %s: %v", strings.ToLower(fields[0]), err)
+			} else if !game.isOver() {
+				notice = "Draw declined."
+			}
+		case "abort":
+			if err := game.abort(); err != nil {
+				notice = fmt.Sprintf("Cannot abort: %v", err)
+			}
+		case "help":
+			notice = helpText
+		default:
+			from, to, ok := parseMoveInput(scanner.Text())
+			if !ok {
+				notice = "Invalid move format. Use 'from to' (e.g., e2 e4)."
+			} else if err := game.movePiece(from, to); err != nil {
+				notice = fmt.Sprintf("Cannot move: %v", err)
 			}
 		}
 	}
 }
+
+const helpText = `Commands:
+  e2 e4, e2-e4, e2e4  play a move; after an undo it starts a new variation
+  undo, redo          step back and forward along the current line
+  variations          list the continuations here and the whole game tree
+  branch N            follow continuation N from here (1 is the main line)
+  promote             make the current line the main line
+  goto ID             jump to a node of the game tree (0 is the start)
+  note TEXT           comment on the last move (at the start: on the game)
+  nag SYMBOL          annotate the last move with !, ?, !!, ??, !? or ?!
+  export FILE         save the game with all variations as PGN
+  load FILE           load the first game of a PGN file
+  resign              resign the game for the side to move
+  draw                offer a draw with your move; the opponent answers 'accept' or 'decline'
+  abort               stop the game without a result
+  quit                leave the game`
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+// perft counts the move sequences of the given length from the current
+// position.
+func perft(c *ChessGame, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	count := 0
+	for fromRow := 0; fromRow < boardSize; fromRow++ {
+		for fromCol := 0; fromCol < boardSize; fromCol++ {
+			piece := c.board[fromRow][fromCol]
+			if piece == "" || isWhitePiece(piece) != c.whiteToMove {
+				continue
+			}
+			for toRow := 0; toRow < boardSize; toRow++ {
+				for toCol := 0; toCol < boardSize; toCol++ {
+					if !c.isLegalMove(fromRow, fromCol, toRow, toCol) {
+						continue
+					}
+					move := c.newMove(fromRow, fromCol, toRow, toCol)
+					c.applyMove(move)
+					count += perft(c, depth-1)
+					c.unapplyMove(move)
+				}
+			}
+		}
+	}
+	return count
+}
+
+// TestPerft checks the move generator against the well-known move counts
+// of positions with castling, en passant and pins. Underpromotion is not
+// supported, so the depths stop before any promotion.
+func TestPerft(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth int
+		want  int
+	}{
+		{"start", standardStartFEN, 3, 8902},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 3, 97862},
+		{"rook endgame", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 4, 43238},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewChessGame()
+			if err := game.loadFEN(tt.fen); err != nil {
+				t.Fatal(err)
+			}
+			if got := perft(game, tt.depth); got != tt.want {
+				t.Errorf("perft(%d) = %d, want %d", tt.depth, got, tt.want)
+			}
+			if got := game.fen(); got != tt.fen {
+				t.Errorf("position after perft = %q, want %q", got, tt.fen)
+			}
+		})
+	}
+}
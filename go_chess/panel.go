@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var pieceValues = map[string]int{"P": 1, "N": 3, "B": 3, "R": 5, "Q": 9, "K": 0}
+
+// sidePanel returns the lines printed to the right of the board: the
+// numbered move list, the captured pieces and the material balance. Only
+// the most recent moves are shown when the list is taller than height.
+func (c *ChessGame) sidePanel(height int) []string {
+	rows := c.moveListRows()
+	footer := []string{
+		"",
+		"Captured by White: " + c.capturedBy(true),
+		"Captured by Black: " + c.capturedBy(false),
+		"Material: " + c.materialBalance(),
+	}
+	if c.db != nil {
+		footer = append(footer, "Database: "+c.db.positionStats(c.zobrist()).String())
+	}
+	if c.isOver() {
+		footer = append(footer, fmt.Sprintf("Result: %s, %s", c.resultTag(), c.resultText()))
+	} else if c.drawOffer != "" {
+		footer = append(footer, c.drawOffer+" offers a draw")
+	}
+
+	available := height - len(footer) - 1
+	if len(rows) > available {
+		rows = rows[len(rows)-available:]
+	}
+
+	panel := append([]string{"Moves"}, rows...)
+	if len(rows) == 0 {
+		panel = append(panel, "  (none yet)")
+	}
+	panel = append(panel, footer...)
+	for len(panel) < height {
+		panel = append(panel, "")
+	}
+	return panel
+}
+
+// moveListRows formats the current line as numbered SAN rows, e.g.
+// "12. Nf3!? Nc6", each followed by the comments on its moves.
+func (c *ChessGame) moveListRows() []string {
+	var rows []string
+	moves := c.line()
+	number := 1
+	for i := 0; i < len(moves); i++ {
+		pair := []Move{moves[i]}
+		white, black := "...", ""
+		if isWhitePiece(moves[i].piece) {
+			white = moves[i].annotatedSAN()
+			if i+1 < len(moves) && !isWhitePiece(moves[i+1].piece) {
+				i++
+				black = moves[i].annotatedSAN()
+				pair = append(pair, moves[i])
+			}
+		} else {
+			black = moves[i].annotatedSAN()
+		}
+		rows = append(rows, fmt.Sprintf("%3d. %-8s %s", number, white, black))
+		for _, m := range pair {
+			if m.comment != "" {
+				rows = append(rows, "     {"+truncate(m.comment, 30)+"}")
+			}
+		}
+		number++
+	}
+	return rows
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-3]) + "..."
+}
+
+func (c *ChessGame) capturedBy(white bool) string {
+	var sb strings.Builder
+	for _, m := range c.line() {
+		if m.capturedPiece != "" && isWhitePiece(m.capturedPiece) != white {
+			sb.WriteString(unicodePieces[m.capturedPiece])
+		}
+	}
+	return sb.String()
+}
+
+// materialBalance compares the material left on the board, e.g. "White +3".
+func (c *ChessGame) materialBalance() string {
+	balance := 0
+	for row := 0; row < boardSize; row++ {
+		for col := 0; col < boardSize; col++ {
+			piece := c.board[row][col]
+			if piece == "" {
+				continue
+			}
+			if isWhitePiece(piece) {
+				balance += pieceValues[piece]
+			} else {
+				balance -= pieceValues[strings.ToUpper(piece)]
+			}
+		}
+	}
+
+	switch {
+	case balance > 0:
+		return fmt.Sprintf("White +%d", balance)
+	case balance < 0:
+		return fmt.Sprintf("Black +%d", -balance)
+	default:
+		return "even"
+	}
+}
+
+// displayWidth is the number of terminal columns a board line takes up; the
+// square glyphs are double width.
+func displayWidth(s string) int {
+	return utf8.RuneCountInString(s) + strings.Count(s, boardColors[0]) + strings.Count(s, boardColors[1])
+}
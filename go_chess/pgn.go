@@ -0,0 +1,394 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const standardStartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// pgn returns the game in Portable Game Notation. Variations are written in
+// parentheses after the main line move they replace, annotations follow the
+// SAN and comments are written in braces.
+func (c *ChessGame) pgn() string {
+	roster := [][2]string{
+		{"Event", "Casual game"},
+		{"Site", "go_chess"},
+		{"Date", time.Now().Format("2006.01.02")},
+		{"Round", "-"},
+		{"White", "White"},
+		{"Black", "Black"},
+	}
+	tags := make([][2]string, 0, len(roster)+len(c.tags)+4)
+	for _, tag := range roster {
+		if value, ok := c.tags[tag[0]]; ok {
+			tag[1] = value
+		}
+		tags = append(tags, tag)
+	}
+	tags = append(tags, [2]string{"Result", c.resultTag()})
+	if c.isOver() {
+		tags = append(tags, [2]string{"Termination", c.pgnTermination()})
+	}
+	if c.termination != "" {
+		// The standard Termination values do not say how a game ended
+		// normally, so the reason itself goes in a tag of its own.
+		tags = append(tags, [2]string{"TerminationDetail", string(c.termination)})
+	}
+	if start := c.startFEN(); start != standardStartFEN {
+		tags = append(tags, [2]string{"SetUp", "1"}, [2]string{"FEN", start})
+	}
+	var extra []string
+	for name := range c.tags {
+		switch name {
+		case "Event", "Site", "Date", "Round", "White", "Black", "Result", "Termination", "TerminationDetail", "SetUp", "FEN":
+		default:
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		tags = append(tags, [2]string{name, c.tags[name]})
+	}
+
+	var sb strings.Builder
+	for _, tag := range tags {
+		fmt.Fprintf(&sb, "[%s \"%s\"]\n", tag[0], strings.ReplaceAll(tag[1], `"`, `\"`))
+	}
+	sb.WriteByte('\n')
+
+	var moves strings.Builder
+	if c.root.move.comment != "" {
+		moves.WriteString("{" + c.root.move.comment + "} ")
+	}
+	c.writeVariation(&moves, c.root, true, func(n *GameNode) string {
+		if n.move.comment == "" {
+			return ""
+		}
+		return "{" + n.move.comment + "}"
+	})
+	if moves.Len() > 0 && !strings.HasSuffix(moves.String(), " ") {
+		moves.WriteByte(' ')
+	}
+	moves.WriteString(c.resultTag())
+	sb.WriteString(wrapText(moves.String(), 80))
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+// startFEN describes the position the game started from.
+func (c *ChessGame) startFEN() string {
+	return positionFEN(c.startBoard, c.startWhiteToMove, c.startCastling, c.startEnPassant, 1)
+}
+
+func (c *ChessGame) exportPGN(path string) error {
+	return os.WriteFile(path, []byte(c.pgn()), 0644)
+}
+
+// importPGN loads the first game of a PGN file that can be read.
+func importPGN(path string) (*ChessGame, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	games, skipped, err := readPGN(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 && len(skipped) > 0 {
+		return nil, skipped[0]
+	}
+	if len(games) == 0 {
+		return nil, fmt.Errorf("%s: no games found", path)
+	}
+	return games[0], nil
+}
+
+// wrapText breaks s into lines of at most width characters at spaces.
+func wrapText(s string, width int) string {
+	var sb strings.Builder
+	lineLen := 0
+	for _, word := range strings.Fields(s) {
+		if lineLen > 0 && lineLen+1+len(word) > width {
+			sb.WriteByte('\n')
+			lineLen = 0
+		} else if lineLen > 0 {
+			sb.WriteByte(' ')
+			lineLen++
+		}
+		sb.WriteString(word)
+		lineLen += len(word)
+	}
+	return sb.String()
+}
+
+type pgnTokenKind int
+
+const (
+	pgnTag pgnTokenKind = iota
+	pgnMove
+	pgnComment
+	pgnNAG
+	pgnOpen
+	pgnClose
+	pgnResult
+)
+
+type pgnToken struct {
+	kind  pgnTokenKind
+	text  string
+	value string // tag value
+	line  int
+}
+
+// tokenizePGN splits PGN text into tags, moves, comments, NAGs,
+// parentheses and results. Move numbers are dropped and suffix
+// annotations such as "!?" become separate NAG tokens.
+func tokenizePGN(text string) ([]pgnToken, error) {
+	var tokens []pgnToken
+	line := 1
+	for i := 0; i < len(text); {
+		ch := text[i]
+		switch {
+		case ch == '\n':
+			line++
+			i++
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+		case ch == '%' && (i == 0 || text[i-1] == '\n'):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case ch == ';':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			tokens = append(tokens, pgnToken{kind: pgnComment, text: strings.TrimSpace(text[i+1 : i+end]), line: line})
+			i += end
+		case ch == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			comment := text[i+1 : i+end]
+			tokens = append(tokens, pgnToken{kind: pgnComment, text: strings.Join(strings.Fields(comment), " "), line: line})
+			line += strings.Count(comment, "\n")
+			i += end + 1
+		case ch == '[':
+			end := strings.IndexByte(text[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated tag", line)
+			}
+			body := strings.TrimSpace(text[i+1 : i+end])
+			name, value, ok := strings.Cut(body, " ")
+			if !ok {
+				return nil, fmt.Errorf("line %d: malformed tag %q", line, body)
+			}
+			value = strings.TrimSpace(value)
+			value = strings.TrimSuffix(strings.TrimPrefix(value, `"`), `"`)
+			value = strings.ReplaceAll(value, `\"`, `"`)
+			tokens = append(tokens, pgnToken{kind: pgnTag, text: name, value: value, line: line})
+			i += end + 1
+		case ch == '(':
+			tokens = append(tokens, pgnToken{kind: pgnOpen, line: line})
+			i++
+		case ch == ')':
+			tokens = append(tokens, pgnToken{kind: pgnClose, line: line})
+			i++
+		default:
+			start := i
+			for i < len(text) && !strings.ContainsRune(" \t\r\n{}()[];", rune(text[i])) {
+				i++
+			}
+			tokens = append(tokens, symbolTokens(text[start:i], line)...)
+		}
+	}
+	return tokens, nil
+}
+
+func symbolTokens(symbol string, line int) []pgnToken {
+	if isResultToken(symbol) {
+		return []pgnToken{{kind: pgnResult, text: symbol, line: line}}
+	}
+	if strings.HasPrefix(symbol, "$") {
+		return []pgnToken{{kind: pgnNAG, text: symbol, line: line}}
+	}
+	if symbol == "e.p." {
+		return nil // some files mark en passant captures after the SAN
+	}
+
+	// Drop a move number such as "12." or "12..." glued to the move.
+	digits := strings.TrimLeft(symbol, "0123456789")
+	if digits != symbol && strings.HasPrefix(digits, ".") {
+		symbol = strings.TrimLeft(digits, ".")
+	}
+	if symbol == "" {
+		return nil
+	}
+
+	san := strings.TrimRight(symbol, "!?")
+	tokens := []pgnToken{{kind: pgnMove, text: san, line: line}}
+	if suffix := symbol[len(san):]; suffix != "" {
+		tokens = append(tokens, pgnToken{kind: pgnNAG, text: suffix, line: line})
+	}
+	return tokens
+}
+
+// readPGN parses every game in text, keeping tags, comments, annotations
+// and variations. A game that cannot be read, such as one with a move
+// go_chess cannot play, is left out and its error returned in skipped;
+// the games after it are still read. err is set when the text as a whole
+// is not PGN.
+func readPGN(text string) (games []*ChessGame, skipped []error, err error) {
+	tokens, err := tokenizePGN(text)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var game *ChessGame
+	var tags map[string]string
+	var variations []*GameNode
+	var bad error // why the current game cannot be read
+
+	begin := func(line int) {
+		game = NewChessGame()
+		if fen, ok := tags["FEN"]; ok {
+			if err := game.loadFEN(fen); err != nil {
+				bad = fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+		game.tags = tags
+		if game.tags == nil {
+			game.tags = map[string]string{}
+		}
+		variations = nil
+	}
+	end := func() {
+		number := len(games) + len(skipped) + 1
+		if bad != nil {
+			skipped = append(skipped, fmt.Errorf("game %d (%s - %s): %v", number, game.tags["White"], game.tags["Black"], bad))
+		} else {
+			game.finishImport()
+			games = append(games, game)
+		}
+		game, tags, bad = nil, nil, nil
+	}
+	// fail marks the current game as unreadable; its remaining moves are
+	// skipped up to its result or the next game's tags.
+	fail := func(line int, format string, args ...any) {
+		if bad == nil {
+			bad = fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+		}
+	}
+
+	for _, tok := range tokens {
+		if tok.kind == pgnTag {
+			if game != nil {
+				end()
+			}
+			if tags == nil {
+				tags = map[string]string{}
+			}
+			tags[tok.text] = tok.value
+			continue
+		}
+		if game == nil {
+			begin(tok.line)
+		}
+		if bad != nil {
+			if tok.kind == pgnResult {
+				end()
+			}
+			continue
+		}
+
+		switch tok.kind {
+		case pgnMove:
+			from, to, err := game.resolveMove(tok.text)
+			if err != nil {
+				fail(tok.line, "%s: %v", tok.text, err)
+				continue
+			}
+			fromRow, fromCol := parsePosition(from)
+			toRow, toCol := parsePosition(to)
+			game.playMove(fromRow, fromCol, toRow, toCol)
+		case pgnComment:
+			if existing := game.current.move.comment; existing != "" {
+				game.comment(existing + " " + tok.text)
+			} else {
+				game.comment(tok.text)
+			}
+		case pgnNAG:
+			if err := game.annotate(tok.text); err != nil {
+				fail(tok.line, "%v", err)
+			}
+		case pgnOpen:
+			if game.current.parent == nil {
+				fail(tok.line, "variation before the first move")
+				continue
+			}
+			variations = append(variations, game.current)
+			game.jumpTo(game.current.parent.id)
+		case pgnClose:
+			if len(variations) == 0 {
+				fail(tok.line, "unmatched )")
+				continue
+			}
+			game.jumpTo(variations[len(variations)-1].id)
+			variations = variations[:len(variations)-1]
+		case pgnResult:
+			if len(variations) > 0 {
+				fail(tok.line, "unclosed variation")
+			}
+			if _, ok := game.tags["Result"]; !ok {
+				game.tags["Result"] = tok.text
+			}
+			end()
+		}
+	}
+	if game != nil {
+		end()
+	}
+	return games, skipped, nil
+}
+
+// finishImport turns the Result, Termination and TerminationDetail tags
+// into the game result and moves the result tags out of the extra tags.
+func (c *ChessGame) finishImport() {
+	result := c.tags["Result"]
+	termination := strings.ToLower(c.tags["Termination"])
+	if detail, ok := c.tags["TerminationDetail"]; ok {
+		termination = strings.ToLower(detail)
+	}
+	delete(c.tags, "Result")
+	delete(c.tags, "Termination")
+	delete(c.tags, "TerminationDetail")
+	delete(c.tags, "SetUp")
+	delete(c.tags, "FEN")
+
+	// Moving through the variations may have left a result behind.
+	c.result, c.termination = "", ""
+	switch result {
+	case WhiteWins, BlackWins, Draw:
+		c.result = result
+	}
+	for _, t := range []Termination{Checkmate, Stalemate, Resignation, Agreement, Repetition, Aborted} {
+		if strings.Contains(termination, string(t)) {
+			c.termination = t
+			break
+		}
+	}
+	if termination == "abandoned" && c.result == "" {
+		c.termination = Aborted
+	}
+	if c.termination == "" && c.result != "" && !c.hasLegalMove(c.whiteToMove) {
+		c.termination = Stalemate
+		if c.isInCheck(c.whiteToMove) {
+			c.termination = Checkmate
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPGNSkipsUnreadableGames(t *testing.T) {
+	text := `[White "A"]
+[Black "B"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Nf6 4. O-O Bc5 5. d4 exd4 6. e5 d5 7. exd6 O-O 1/2-1/2
+
+[White "C"]
+[Black "D"]
+[SetUp "1"]
+[FEN "8/P7/8/8/8/8/k7/4K3 w - - 0 1"]
+
+1. a8=N *
+
+[White "E"]
+[Black "F"]
+
+1. f3 e5 2. g4 Qh4# 0-1
+`
+	games, skipped, err := readPGN(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 || len(skipped) != 1 {
+		t.Fatalf("read %d games and skipped %d, want 2 and 1", len(games), len(skipped))
+	}
+	if !strings.Contains(skipped[0].Error(), "game 2 (C - D)") || !strings.Contains(skipped[0].Error(), "a8=N") {
+		t.Errorf("skipped: %v", skipped[0])
+	}
+
+	var sans []string
+	for _, m := range games[0].line() {
+		sans = append(sans, m.san)
+	}
+	if got, want := strings.Join(sans, " "), "e4 e5 Nf3 Nc6 Bc4 Nf6 O-O Bc5 d4 exd4 e5 d5 exd6 O-O"; got != want {
+		t.Errorf("moves = %q, want %q", got, want)
+	}
+	if got, want := games[0].fen(), "r1bq1rk1/ppp2ppp/2nP1n2/2b5/2Bp4/5N2/PPP2PPP/RNBQ1RK1 w - - 0 8"; got != want {
+		t.Errorf("FEN = %q, want %q", got, want)
+	}
+	if games[1].result != BlackWins || games[1].termination != Checkmate {
+		t.Errorf("third game: %s (%s)", games[1].result, games[1].termination)
+	}
+}
+
+func TestPGNKeepsTermination(t *testing.T) {
+	tests := []struct {
+		fen    string
+		moves  string
+		end    func(*ChessGame) error
+		result string
+		want   Termination
+	}{
+		{"", "f3 e5 g4 Qh4#", nil, BlackWins, Checkmate},
+		{"k7/8/8/1Q6/8/8/8/K7 w - - 0 1", "Qb6", nil, Draw, Stalemate},
+		{"", "e4", (*ChessGame).resign, WhiteWins, Resignation},
+		{"", "e4 e5", func(c *ChessGame) error {
+			if err := c.offerDraw(); err != nil {
+				return err
+			}
+			if err := c.movePiece("g1", "f3"); err != nil {
+				return err
+			}
+			return c.answerDraw(true)
+		}, Draw, Agreement},
+		{"", "Nf3 Nf6 Ng1 Ng8 Nf3 Nf6 Ng1 Ng8", nil, Draw, Repetition},
+		{"", "d4", (*ChessGame).abort, "", Aborted},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.want), func(t *testing.T) {
+			game := NewChessGame()
+			if tt.fen != "" {
+				if err := game.loadFEN(tt.fen); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, san := range strings.Fields(tt.moves) {
+				from, to, err := game.resolveMove(san)
+				if err != nil {
+					t.Fatalf("%s: %v", san, err)
+				}
+				if err := game.movePiece(from, to); err != nil {
+					t.Fatalf("%s: %v", san, err)
+				}
+			}
+			if tt.end != nil {
+				if err := tt.end(game); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if game.result != tt.result || game.termination != tt.want {
+				t.Fatalf("played: %s", game.resultText())
+			}
+
+			games, skipped, err := readPGN(game.pgn())
+			if err != nil || len(skipped) > 0 || len(games) != 1 {
+				t.Fatalf("read back %d games, skipped %v, error %v", len(games), skipped, err)
+			}
+			if got := games[0]; got.result != tt.result || got.termination != tt.want {
+				t.Errorf("read back %q (%s), want %q (%s)", got.result, got.termination, tt.result, tt.want)
+			}
+		})
+	}
+}
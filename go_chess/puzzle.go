@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPuzzleRating = 1500
+	puzzleRatingK       = 32
+)
+
+// Puzzle is a position together with the line that solves it. Solution
+// alternates between the player's moves and the opponent's replies and is
+// kept in from-to squares, e.g. "e2e4", however the file wrote them.
+type Puzzle struct {
+	ID       string
+	FEN      string
+	Solution []string
+	Rating   int
+}
+
+type PuzzleRecord struct {
+	Attempts   int       `json:"attempts"`
+	Solved     int       `json:"solved"`
+	WrongTries int       `json:"wrong_tries"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+type PuzzleStats struct {
+	Rating  int                      `json:"rating"`
+	Puzzles map[string]*PuzzleRecord `json:"puzzles"`
+}
+
+// loadPuzzles reads puzzles from a text file with one puzzle per line in
+// the form "id;FEN;moves[;rating]". Blank lines and lines starting with #
+// are skipped. Every solution is replayed to make sure it is legal.
+func loadPuzzles(path string) ([]Puzzle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var puzzles []Puzzle
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ";")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%s:%d: expected id;FEN;moves[;rating]", path, lineNo)
+		}
+		p := Puzzle{
+			ID:       strings.TrimSpace(fields[0]),
+			FEN:      strings.TrimSpace(fields[1]),
+			Solution: strings.Fields(strings.ToLower(fields[2])),
+			Rating:   defaultPuzzleRating,
+		}
+		if len(fields) > 3 {
+			p.Rating, err = strconv.Atoi(strings.TrimSpace(fields[3]))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid rating: %v", path, lineNo, err)
+			}
+		}
+		for i, move := range p.Solution {
+			if from, to, ok := parseMoveInput(move); ok {
+				p.Solution[i] = from + to
+			}
+		}
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, lineNo, err)
+		}
+		puzzles = append(puzzles, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(puzzles) == 0 {
+		return nil, fmt.Errorf("%s: no puzzles found", path)
+	}
+	return puzzles, nil
+}
+
+func (p Puzzle) validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("puzzle has no id")
+	}
+	if len(p.Solution) == 0 {
+		return fmt.Errorf("puzzle %s has no solution", p.ID)
+	}
+
+	game := NewChessGame()
+	if err := game.loadFEN(p.FEN); err != nil {
+		return err
+	}
+	for _, move := range p.Solution {
+		from, to, ok := parseMoveInput(move)
+		if !ok {
+			return fmt.Errorf("puzzle %s: invalid move %q", p.ID, move)
+		}
+		fromRow, fromCol := parsePosition(from)
+		toRow, toCol := parsePosition(to)
+		if fromRow == -1 || toRow == -1 || !game.isLegalMove(fromRow, fromCol, toRow, toCol) ||
+			isWhitePiece(game.board[fromRow][fromCol]) != game.whiteToMove {
+			return fmt.Errorf("puzzle %s: illegal move %q", p.ID, move)
+		}
+		game.movePiece(from, to)
+	}
+	return nil
+}
+
+func getPuzzleStatsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "puzzle-stats.json"
+	}
+	dataDir := filepath.Join(homeDir, ".local", "share", "go-chess")
+	os.MkdirAll(dataDir, 0755)
+	return filepath.Join(dataDir, "puzzle-stats.json")
+}
+
+func loadPuzzleStats() *PuzzleStats {
+	stats := &PuzzleStats{Rating: defaultPuzzleRating, Puzzles: map[string]*PuzzleRecord{}}
+	data, err := os.ReadFile(getPuzzleStatsPath())
+	if err != nil {
+		return stats
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return &PuzzleStats{Rating: defaultPuzzleRating, Puzzles: map[string]*PuzzleRecord{}}
+	}
+	if stats.Puzzles == nil {
+		stats.Puzzles = map[string]*PuzzleRecord{}
+	}
+	return stats
+}
+
+func savePuzzleStats(stats *PuzzleStats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getPuzzleStatsPath(), data, 0644)
+}
+
+func (s *PuzzleStats) record(id string) *PuzzleRecord {
+	r, ok := s.Puzzles[id]
+	if !ok {
+		r = &PuzzleRecord{}
+		s.Puzzles[id] = r
+	}
+	return r
+}
+
+// updateRating applies an Elo update against the puzzle's rating. A puzzle
+// only counts as a win when it was solved without a wrong try.
+func (s *PuzzleStats) updateRating(puzzleRating int, won bool) int {
+	expected := 1 / (1 + math.Pow(10, float64(puzzleRating-s.Rating)/400))
+	score := 0.0
+	if won {
+		score = 1
+	}
+	delta := int(math.Round(puzzleRatingK * (score - expected)))
+	s.Rating += delta
+	return delta
+}
+
+func runPuzzles(puzzles []Puzzle, scanner *bufio.Scanner) {
+	stats := loadPuzzleStats()
+	for i, p := range puzzles {
+		quit := playPuzzle(p, i+1, len(puzzles), stats, scanner)
+		if err := savePuzzleStats(stats); err != nil {
+			fmt.Println("Could not save puzzle statistics:", err)
+		}
+		if quit {
+			return
+		}
+	}
+	fmt.Printf("All puzzles done. Your puzzle rating: %d\n", stats.Rating)
+}
+
+// playPuzzle runs a single puzzle and reports whether the player asked to
+// quit. The opponent's replies from the solution are played automatically.
+func playPuzzle(p Puzzle, number, total int, stats *PuzzleStats, scanner *bufio.Scanner) bool {
+	game := NewChessGame()
+	game.loadFEN(p.FEN)
+	player := "White"
+	if !game.whiteToMove {
+		player = "Black"
+	}
+
+	record := stats.record(p.ID)
+	record.Attempts++
+	record.LastPlayed = time.Now()
+
+	step, wrong := 0, 0
+	status := ""
+	gaveUp := false
+	for step < len(p.Solution) {
+		clearTerminal()
+		game.printBoard()
+		fmt.Printf("\nPuzzle %d/%d (%s, rating %d) - your rating %d\n", number, total, p.ID, p.Rating, stats.Rating)
+		fmt.Printf("%s to play and win. Wrong tries: %d\n", player, wrong)
+		if status != "" {
+			fmt.Println(status)
+		}
+		fmt.Print("Your move (e.g., e2-e4, or 'skip', or 'quit'): ")
+		if !scanner.Scan() {
+			return true
+		}
+		input := strings.ToLower(strings.TrimSpace(scanner.Text()))
+
+		if input == "quit" {
+			return true
+		} else if input == "skip" {
+			gaveUp = true
+			break
+		}
+
+		from, to, ok := parseMoveInput(input)
+		if !ok {
+			status = "Invalid move format. Use 'from to' (e.g., e2 e4)."
+			continue
+		}
+		fromRow, fromCol := parsePosition(from)
+		toRow, toCol := parsePosition(to)
+		if fromRow == -1 || toRow == -1 || !game.isLegalMove(fromRow, fromCol, toRow, toCol) ||
+			isWhitePiece(game.board[fromRow][fromCol]) != game.whiteToMove {
+			status = "Illegal move!"
+			continue
+		}
+		if from+to != p.Solution[step] {
+			wrong++
+			status = fmt.Sprintf("%s-%s is not the best move. Try again.", from, to)
+			continue
+		}
+
+		game.movePiece(from, to)
+		step++
+		status = "Correct!"
+		if step < len(p.Solution) {
+			reply := p.Solution[step]
+			game.movePiece(reply[:2], reply[2:])
+			step++
+			status = fmt.Sprintf("Correct! Opponent replied %s-%s.", reply[:2], reply[2:])
+		}
+	}
+
+	solved := !gaveUp
+	record.WrongTries += wrong
+	if solved {
+		record.Solved++
+	}
+	delta := stats.updateRating(p.Rating, solved && wrong == 0)
+
+	clearTerminal()
+	game.printBoard()
+	fmt.Println()
+	if solved {
+		fmt.Printf("Puzzle %s solved with %d wrong tries.\n", p.ID, wrong)
+	} else {
+		fmt.Printf("Puzzle %s failed. Solution: %s\n", p.ID, strings.Join(p.Solution, " "))
+	}
+	fmt.Printf("Rating: %d (%+d). Solved %d of %d attempts.\n", stats.Rating, delta, record.Solved, record.Attempts)
+	fmt.Print("Press ENTER for the next puzzle, or type 'quit': ")
+	if !scanner.Scan() {
+		return true
+	}
+	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "quit"
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPuzzleWithDashedMoves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "puzzles.txt")
+	// Scholar's mate, written with dashes and mixed case: Qh5 Nf6 Qxf7#.
+	line := "dashed;r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/8/PPPP1PPP/RNBQK1NR w KQkq - 0 3;D1-H5 g8-f6 h5-F7;1400\n"
+	if err := os.WriteFile(path, []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+	puzzles, err := loadPuzzles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(puzzles[0].Solution, " "), "d1h5 g8f6 h5f7"; got != want {
+		t.Fatalf("solution %q, want %q", got, want)
+	}
+
+	stats := &PuzzleStats{Rating: defaultPuzzleRating, Puzzles: map[string]*PuzzleRecord{}}
+	scanner := bufio.NewScanner(strings.NewReader("d1-h5\nh5 f7\nquit\n"))
+	if quit := playPuzzle(puzzles[0], 1, 1, stats, scanner); !quit {
+		t.Errorf("playPuzzle did not quit")
+	}
+	if r := stats.Puzzles["dashed"]; r.Solved != 1 || r.WrongTries != 0 {
+		t.Errorf("record %+v, want solved without wrong tries", r)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Termination explains how a game ended.
+type Termination string
+
+const (
+	Checkmate   Termination = "checkmate"
+	Stalemate   Termination = "stalemate"
+	Resignation Termination = "resignation"
+	Agreement   Termination = "agreement"
+	Repetition  Termination = "repetition"
+	Aborted     Termination = "aborted"
+)
+
+const (
+	WhiteWins = "1-0"
+	BlackWins = "0-1"
+	Draw      = "1/2-1/2"
+	NoResult  = "*"
+)
+
+func (c *ChessGame) isOver() bool {
+	return c.result != "" || c.termination != ""
+}
+
+func (c *ChessGame) finish(result string, termination Termination) {
+	c.result = result
+	c.termination = termination
+	c.drawOffer = ""
+}
+
+// resultTag is the PGN result, "*" while the game is running or when it
+// was aborted.
+func (c *ChessGame) resultTag() string {
+	if c.result == "" {
+		return NoResult
+	}
+	return c.result
+}
+
+// pgnTermination is the value of the PGN Termination tag. Games decided on
+// the board or by the players ended "normal"; an aborted game was
+// "abandoned".
+func (c *ChessGame) pgnTermination() string {
+	if c.termination == Aborted {
+		return "abandoned"
+	}
+	return "normal"
+}
+
+// resultText describes the result for people, e.g. "White won by
+// checkmate".
+func (c *ChessGame) resultText() string {
+	if c.termination == "" {
+		switch c.result {
+		case WhiteWins:
+			return "White won"
+		case BlackWins:
+			return "Black won"
+		case Draw:
+			return "Draw"
+		}
+	}
+
+	switch c.result {
+	case WhiteWins:
+		return fmt.Sprintf("White won by %s", c.termination)
+	case BlackWins:
+		return fmt.Sprintf("Black won by %s", c.termination)
+	case Draw:
+		return fmt.Sprintf("Draw by %s", c.termination)
+	}
+	if c.termination == Aborted {
+		return "Game aborted"
+	}
+	return "Game in progress"
+}
+
+func sideName(white bool) string {
+	if white {
+		return "White"
+	}
+	return "Black"
+}
+
+// resign ends the game in favour of the side not to move.
+func (c *ChessGame) resign() error {
+	if c.isOver() {
+		return fmt.Errorf("the game is already over")
+	}
+	if c.whiteToMove {
+		c.finish(BlackWins, Resignation)
+	} else {
+		c.finish(WhiteWins, Resignation)
+	}
+	return nil
+}
+
+// offerDraw records a draw offer from the side to move, made together with
+// its next move. Once that move is played the opponent can accept or
+// decline the offer, or decline it by replying with a move.
+func (c *ChessGame) offerDraw() error {
+	if c.isOver() {
+		return fmt.Errorf("the game is already over")
+	}
+	if c.drawOffer != "" {
+		return fmt.Errorf("%s has already offered a draw", c.drawOffer)
+	}
+	c.drawOffer = sideName(c.whiteToMove)
+	return nil
+}
+
+func (c *ChessGame) answerDraw(accept bool) error {
+	if c.drawOffer == "" {
+		return fmt.Errorf("no draw has been offered")
+	}
+	if c.drawOffer == sideName(c.whiteToMove) {
+		return fmt.Errorf("%s offered the draw and must move first", c.drawOffer)
+	}
+	if accept {
+		c.finish(Draw, Agreement)
+	} else {
+		c.drawOffer = ""
+	}
+	return nil
+}
+
+func (c *ChessGame) abort() error {
+	if c.isOver() {
+		return fmt.Errorf("the game is already over")
+	}
+	c.finish("", Aborted)
+	return nil
+}
+
+// updateResult looks at the result again after moving around the game
+// tree. Checkmate, stalemate and repetition belong to a position, so they
+// are cleared and checked for anew. A resignation, an agreed draw or an
+// abort was the players' decision, and a result read from a PGN file
+// without a known termination is the recorded one; those stand wherever
+// the game goes.
+func (c *ChessGame) updateResult() {
+	switch c.termination {
+	case Resignation, Agreement, Aborted:
+		return
+	case "":
+		if c.result != "" {
+			return
+		}
+	}
+	c.result, c.termination = "", ""
+	c.checkGameEnd()
+}
+
+// checkGameEnd sets the result after a move when the side to move is
+// checkmated or stalemated, or the position occurred for the third time.
+func (c *ChessGame) checkGameEnd() {
+	if !c.hasLegalMove(c.whiteToMove) {
+		if !c.isInCheck(c.whiteToMove) {
+			c.finish(Draw, Stalemate)
+		} else if c.whiteToMove {
+			c.finish(BlackWins, Checkmate)
+		} else {
+			c.finish(WhiteWins, Checkmate)
+		}
+		return
+	}
+	if c.repetitions() >= 3 {
+		c.finish(Draw, Repetition)
+	}
+}
+
+// repetitions counts how often the current position has occurred on the
+// current line, including now. The en passant square is left out: it only
+// follows a pawn move, which no earlier position can repeat.
+func (c *ChessGame) repetitions() int {
+	key := func(g *ChessGame) string {
+		fields := strings.Fields(positionFEN(g.board, g.whiteToMove, g.castling, "", 1))
+		return strings.Join(fields[:3], " ")
+	}
+
+	current := key(c)
+	replay := &ChessGame{board: c.startBoard, whiteToMove: c.startWhiteToMove, castling: c.startCastling}
+	count := 0
+	if key(replay) == current {
+		count++
+	}
+	for _, move := range c.line() {
+		replay.applyMove(move)
+		if key(replay) == current {
+			count++
+		}
+	}
+	return count
+}
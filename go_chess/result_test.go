@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestAnswerDraw(t *testing.T) {
+	game := NewChessGame()
+	if err := game.offerDraw(); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.answerDraw(true); err == nil {
+		t.Fatalf("White accepted its own draw offer: %s", game.resultText())
+	}
+	if err := game.answerDraw(false); err == nil {
+		t.Fatal("White declined its own draw offer")
+	}
+
+	if err := game.movePiece("e2", "e4"); err != nil {
+		t.Fatal(err)
+	}
+	if game.drawOffer != "White" {
+		t.Fatalf("the offer was dropped after White's move")
+	}
+	if err := game.answerDraw(true); err != nil {
+		t.Fatal(err)
+	}
+	if game.result != Draw || game.termination != Agreement {
+		t.Errorf("%s, want a draw by agreement", game.resultText())
+	}
+}
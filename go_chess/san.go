@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func squareName(row, col int) string {
+	return string(rune('a'+col)) + string(rune('8'-row))
+}
+
+// moveSAN returns the Standard Algebraic Notation for a move that has not
+// been played yet, e.g. "Nbd7", "exd5", "O-O" or "Qh5+".
+func (c *ChessGame) moveSAN(fromRow, fromCol, toRow, toCol int) string {
+	move := c.newMove(fromRow, fromCol, toRow, toCol)
+	kind := strings.ToUpper(move.piece)
+
+	var sb strings.Builder
+	switch {
+	case isCastling(move) && toCol > fromCol:
+		sb.WriteString("O-O")
+	case isCastling(move):
+		sb.WriteString("O-O-O")
+	case kind == "P":
+		if move.capturedPiece != "" {
+			sb.WriteByte(byte('a' + fromCol))
+			sb.WriteByte('x')
+		}
+		sb.WriteString(squareName(toRow, toCol))
+		if move.promotion != "" {
+			sb.WriteString("=" + strings.ToUpper(move.promotion))
+		}
+	default:
+		sb.WriteString(kind)
+		sb.WriteString(c.disambiguation(fromRow, fromCol, toRow, toCol))
+		if move.capturedPiece != "" {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(squareName(toRow, toCol))
+	}
+
+	c.applyMove(move)
+	if c.isInCheck(c.whiteToMove) {
+		if c.hasLegalMove(c.whiteToMove) {
+			sb.WriteByte('+')
+		} else {
+			sb.WriteByte('#')
+		}
+	}
+	c.unapplyMove(move)
+
+	return sb.String()
+}
+
+// disambiguation returns the file, rank or square needed to tell this move
+// apart from the same kind of piece reaching the same square.
+func (c *ChessGame) disambiguation(fromRow, fromCol, toRow, toCol int) string {
+	piece := c.board[fromRow][fromCol]
+	ambiguous, sameFile, sameRank := false, false, false
+	for row := 0; row < boardSize; row++ {
+		for col := 0; col < boardSize; col++ {
+			if (row == fromRow && col == fromCol) || c.board[row][col] != piece {
+				continue
+			}
+			if !c.isLegalMove(row, col, toRow, toCol) {
+				continue
+			}
+			ambiguous = true
+			sameFile = sameFile || col == fromCol
+			sameRank = sameRank || row == fromRow
+		}
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return squareName(fromRow, fromCol)[:1]
+	case !sameRank:
+		return squareName(fromRow, fromCol)[1:]
+	default:
+		return squareName(fromRow, fromCol)
+	}
+}
+
+// resolveMove turns a move typed as SAN ("Nf3", "exd5+") or as squares
+// ("g1f3", "g1-f3", or "e7e8q" with the promotion piece as in UCI) into
+// the from and to squares of a legal move for the side to move.
+func (c *ChessGame) resolveMove(token string) (string, string, error) {
+	squares, promotion := token, ""
+	if len(token) == 5 && strings.ContainsRune("qrbnQRBN", rune(token[4])) {
+		squares, promotion = token[:4], strings.ToUpper(token[4:])
+	}
+	if from, to, ok := parseMoveInput(squares); ok {
+		fromRow, fromCol := parsePosition(from)
+		toRow, toCol := parsePosition(to)
+		if fromRow != -1 && toRow != -1 {
+			if c.board[fromRow][fromCol] != "" && isWhitePiece(c.board[fromRow][fromCol]) != c.whiteToMove {
+				return "", "", fmt.Errorf("it is %s's turn", sideName(c.whiteToMove))
+			}
+			if !c.isLegalMove(fromRow, fromCol, toRow, toCol) {
+				return "", "", fmt.Errorf("illegal move")
+			}
+			if promotion != "" && promotion != strings.ToUpper(promotionPiece(c.board[fromRow][fromCol], toRow)) {
+				return "", "", fmt.Errorf("illegal promotion: pawns only promote to a queen on the last rank")
+			}
+			return from, to, nil
+		}
+	}
+
+	want := strings.TrimRight(token, "+#!?")
+	if strings.HasPrefix(want, "0-0") {
+		want = strings.ReplaceAll(want, "0", "O") // castling written with zeros
+	}
+	for _, m := range c.legalMoves() {
+		if strings.TrimRight(m.san, "+#") == want {
+			return squareName(m.fromRow, m.fromCol), squareName(m.toRow, m.toCol), nil
+		}
+	}
+	return "", "", fmt.Errorf("illegal or unrecognized move")
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server exposes ChessGames over a JSON HTTP API:
+//
+//	POST /games                 create a game, optionally {"fen": "..."}
+//	GET  /games/{id}            FEN, side to move, result, moves and legal moves
+//	POST /games/{id}/moves      play {"move": "e4"} (SAN or UCI such as "e2e4" or "e7e8q")
+//	POST /games/{id}/undo       take back the last move, dropping it from the PGN
+//	GET  /games/{id}/pgn        the game as PGN
+//	GET  /games/{id}/events     Server-Sent Events for every move and undo
+type Server struct {
+	mu     sync.Mutex
+	games  map[string]*serverGame
+	nextID int
+}
+
+type serverGame struct {
+	game        *ChessGame
+	subscribers map[chan GameEvent]struct{}
+}
+
+// GameState is the JSON view of a game.
+type GameState struct {
+	ID          string      `json:"id"`
+	FEN         string      `json:"fen"`
+	Turn        string      `json:"turn"`
+	Result      string      `json:"result"`
+	Termination Termination `json:"termination,omitempty"`
+	Moves       []string    `json:"moves"`
+	LegalMoves  []LegalMove `json:"legal_moves"`
+}
+
+type LegalMove struct {
+	SAN string `json:"san"`
+	UCI string `json:"uci"`
+}
+
+// GameEvent is sent to /events subscribers after a move or an undo.
+type GameEvent struct {
+	Type   string `json:"type"`
+	SAN    string `json:"san"`
+	FEN    string `json:"fen"`
+	Result string `json:"result"`
+}
+
+func NewServer() *Server {
+	return &Server{games: map[string]*serverGame{}}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /games", s.createGame)
+	mux.HandleFunc("GET /games/{id}", s.withGame(s.getGame))
+	mux.HandleFunc("POST /games/{id}/moves", s.withGame(s.playMove))
+	mux.HandleFunc("POST /games/{id}/undo", s.withGame(s.undo))
+	mux.HandleFunc("GET /games/{id}/pgn", s.withGame(s.getPGN))
+	mux.HandleFunc("GET /games/{id}/events", s.events)
+	return mux
+}
+
+// withGame looks up the game named in the path and runs h with the server
+// lock held.
+func (s *Server) withGame(h func(http.ResponseWriter, *http.Request, string, *serverGame)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		sg, ok := s.games[id]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no game %q", id))
+			return
+		}
+		h(w, r, id, sg)
+	}
+}
+
+func (s *Server) createGame(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FEN string `json:"fen"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	game := NewChessGame()
+	if req.FEN != "" {
+		if err := game.loadFEN(req.FEN); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.games[id] = &serverGame{game: game, subscribers: map[chan GameEvent]struct{}{}}
+	writeJSON(w, http.StatusCreated, gameState(id, game))
+}
+
+func (s *Server) getGame(w http.ResponseWriter, r *http.Request, id string, sg *serverGame) {
+	writeJSON(w, http.StatusOK, gameState(id, sg.game))
+}
+
+func (s *Server) playMove(w http.ResponseWriter, r *http.Request, id string, sg *serverGame) {
+	var req struct {
+		Move string `json:"move"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	game := sg.game
+	if game.isOver() {
+		writeError(w, http.StatusConflict, fmt.Errorf("the game is over: %s", game.resultText()))
+		return
+	}
+	from, to, err := game.resolveMove(req.Move)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s: %v", req.Move, err))
+		return
+	}
+	game.movePiece(from, to)
+	sg.publish(GameEvent{Type: "move", SAN: game.current.move.san, FEN: game.fen(), Result: game.resultTag()})
+	writeJSON(w, http.StatusOK, gameState(id, game))
+}
+
+func (s *Server) undo(w http.ResponseWriter, r *http.Request, id string, sg *serverGame) {
+	game := sg.game
+	if game.current.parent == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("no moves to undo"))
+		return
+	}
+	san := game.current.move.san
+	game.takeBack()
+	sg.publish(GameEvent{Type: "undo", SAN: san, FEN: game.fen(), Result: game.resultTag()})
+	writeJSON(w, http.StatusOK, gameState(id, game))
+}
+
+func (s *Server) getPGN(w http.ResponseWriter, r *http.Request, id string, sg *serverGame) {
+	w.Header().Set("Content-Type", "application/x-chess-pgn")
+	fmt.Fprint(w, sg.game.pgn())
+}
+
+// events streams a GameEvent for every move and undo until the client
+// goes away.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	id := r.PathValue("id")
+	ch := make(chan GameEvent, 16)
+	s.mu.Lock()
+	sg, ok := s.games[id]
+	if ok {
+		sg.subscribers[ch] = struct{}{}
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no game %q", id))
+		return
+	}
+	defer func() {
+		s.mu.Lock()
+		delete(sg.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// publish sends an event to every subscriber; slow subscribers miss it
+// rather than block the game. The server lock must be held.
+func (sg *serverGame) publish(event GameEvent) {
+	for ch := range sg.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func gameState(id string, game *ChessGame) GameState {
+	state := GameState{
+		ID:          id,
+		FEN:         game.fen(),
+		Turn:        sideName(game.whiteToMove),
+		Result:      game.resultTag(),
+		Termination: game.termination,
+		Moves:       []string{},
+		LegalMoves:  []LegalMove{},
+	}
+	for _, m := range game.line() {
+		state.Moves = append(state.Moves, m.san)
+	}
+	if !game.isOver() {
+		for _, m := range game.legalMoves() {
+			state.LegalMoves = append(state.LegalMoves, LegalMove{SAN: m.san, UCI: uciMove(m)})
+		}
+	}
+	return state
+}
+
+// uciMove writes a move in UCI notation: the from and to squares, and the
+// piece a pawn promotes to in lower case, e.g. "e7e8q".
+func uciMove(m Move) string {
+	return squareName(m.fromRow, m.fromCol) + squareName(m.toRow, m.toCol) + strings.ToLower(m.promotion)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
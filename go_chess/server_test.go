@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(NewServer().Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func doJSON(t *testing.T, method, url, body string, wantStatus int) GameState {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: status %d, want %d (%s)", method, url, resp.StatusCode, wantStatus, data)
+	}
+	var state GameState
+	json.Unmarshal(data, &state)
+	return state
+}
+
+func TestCreateAndGetGame(t *testing.T) {
+	ts := newTestServer(t)
+
+	created := doJSON(t, "POST", ts.URL+"/games", "", http.StatusCreated)
+	if created.FEN != standardStartFEN {
+		t.Errorf("FEN = %q, want %q", created.FEN, standardStartFEN)
+	}
+	if len(created.LegalMoves) != 20 {
+		t.Errorf("got %d legal moves, want 20", len(created.LegalMoves))
+	}
+
+	got := doJSON(t, "GET", ts.URL+"/games/"+created.ID, "", http.StatusOK)
+	if got.ID != created.ID || got.Turn != "White" {
+		t.Errorf("got game %q with %s to move", got.ID, got.Turn)
+	}
+
+	doJSON(t, "GET", ts.URL+"/games/42", "", http.StatusNotFound)
+	doJSON(t, "POST", ts.URL+"/games", `{"fen": "not a fen"}`, http.StatusBadRequest)
+}
+
+func TestPlayMoves(t *testing.T) {
+	ts := newTestServer(t)
+	id := doJSON(t, "POST", ts.URL+"/games", "", http.StatusCreated).ID
+	movesURL := ts.URL + "/games/" + id + "/moves"
+
+	tests := []struct {
+		move       string
+		wantStatus int
+	}{
+		{"e4", http.StatusOK},
+		{"e7e5", http.StatusOK},
+		{"Ke3", http.StatusUnprocessableEntity},
+		{"d7d5", http.StatusUnprocessableEntity},
+		{"Bc4", http.StatusOK},
+		{"Nc6", http.StatusOK},
+		{"Qh5", http.StatusOK},
+		{"Nf6", http.StatusOK},
+		{"Qxf7#", http.StatusOK},
+		{"Ke7", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		doJSON(t, "POST", movesURL, `{"move": "`+tt.move+`"}`, tt.wantStatus)
+	}
+
+	state := doJSON(t, "GET", ts.URL+"/games/"+id, "", http.StatusOK)
+	if state.Result != WhiteWins || state.Termination != Checkmate {
+		t.Errorf("result = %s (%s), want 1-0 by checkmate", state.Result, state.Termination)
+	}
+	if got := strings.Join(state.Moves, " "); got != "e4 e5 Bc4 Nc6 Qh5 Nf6 Qxf7#" {
+		t.Errorf("moves = %q", got)
+	}
+
+	resp, err := http.Get(ts.URL + "/games/" + id + "/pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	pgn, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(pgn), `[Termination "normal"]`) {
+		t.Errorf("PGN without a standard Termination tag:\n%s", pgn)
+	}
+
+	// Promotions are listed and accepted in UCI with the piece letter.
+	id = doJSON(t, "POST", ts.URL+"/games", `{"fen": "4k3/P7/8/8/8/8/8/4K3 w - - 0 1"}`, http.StatusCreated).ID
+	state = doJSON(t, "GET", ts.URL+"/games/"+id, "", http.StatusOK)
+	found := false
+	for _, m := range state.LegalMoves {
+		found = found || m.SAN == "a8=Q+" && m.UCI == "a7a8q"
+	}
+	if !found {
+		t.Errorf("a8=Q+ is not listed as a7a8q in %v", state.LegalMoves)
+	}
+	movesURL = ts.URL + "/games/" + id + "/moves"
+	doJSON(t, "POST", movesURL, `{"move": "a7a8n"}`, http.StatusUnprocessableEntity)
+	state = doJSON(t, "POST", movesURL, `{"move": "a7a8q"}`, http.StatusOK)
+	if got := strings.Join(state.Moves, " "); got != "a8=Q+" {
+		t.Errorf("moves = %q, want a8=Q+", got)
+	}
+}
+
+func TestUndoAndPGN(t *testing.T) {
+	ts := newTestServer(t)
+	id := doJSON(t, "POST", ts.URL+"/games", "", http.StatusCreated).ID
+	gameURL := ts.URL + "/games/" + id
+
+	doJSON(t, "POST", gameURL+"/undo", "", http.StatusConflict)
+	doJSON(t, "POST", gameURL+"/moves", `{"move": "d4"}`, http.StatusOK)
+	doJSON(t, "POST", gameURL+"/moves", `{"move": "d5"}`, http.StatusOK)
+	state := doJSON(t, "POST", gameURL+"/undo", "", http.StatusOK)
+	if len(state.Moves) != 1 || state.Turn != "Black" {
+		t.Errorf("after undo: moves %v, %s to move", state.Moves, state.Turn)
+	}
+	state = doJSON(t, "POST", gameURL+"/moves", `{"move": "e5"}`, http.StatusOK)
+	if got := strings.Join(state.Moves, " "); got != "d4 e5" {
+		t.Errorf("after undo and e5: moves = %q", got)
+	}
+
+	resp, err := http.Get(gameURL + "/pgn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	pgn, _ := io.ReadAll(resp.Body)
+	// The undone move is gone, not kept as a variation.
+	if !strings.Contains(string(pgn), "\n1. d4 e5 *") || strings.Contains(string(pgn), "d5") {
+		t.Errorf("unexpected PGN:\n%s", pgn)
+	}
+}
+
+func TestUndoAfterMate(t *testing.T) {
+	ts := newTestServer(t)
+	id := doJSON(t, "POST", ts.URL+"/games", "", http.StatusCreated).ID
+	gameURL := ts.URL + "/games/" + id
+
+	for _, move := range []string{"f3", "e5", "g4", "Qh4#"} {
+		doJSON(t, "POST", gameURL+"/moves", `{"move": "`+move+`"}`, http.StatusOK)
+	}
+	state := doJSON(t, "POST", gameURL+"/undo", "", http.StatusOK)
+	if state.Result != NoResult || state.Termination != "" || len(state.LegalMoves) == 0 {
+		t.Errorf("after undoing the mate: result %s (%s), %d legal moves", state.Result, state.Termination, len(state.LegalMoves))
+	}
+	doJSON(t, "POST", gameURL+"/moves", `{"move": "Qe7"}`, http.StatusOK)
+}
+
+func TestEventStream(t *testing.T) {
+	ts := newTestServer(t)
+	id := doJSON(t, "POST", ts.URL+"/games", "", http.StatusCreated).ID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/games/"+id+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	doJSON(t, "POST", ts.URL+"/games/"+id+"/moves", `{"move": "Nf3"}`, http.StatusOK)
+
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() && scanner.Text() != "" {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 2 || lines[0] != "event: move" {
+		t.Fatalf("got event %q", lines)
+	}
+	var event GameEvent
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.SAN != "Nf3" || event.Result != NoResult {
+		t.Errorf("event = %+v", event)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GameNode is a position in the game tree. The root stands for the starting
+// position and has no move; every other node holds the move that led to it.
+// children[0] is the main line and the rest are variations.
+type GameNode struct {
+	id       int
+	ply      int
+	move     Move
+	parent   *GameNode
+	children []*GameNode
+	selected *GameNode // child that redo follows, the main line if nil
+}
+
+// newTree starts an empty game tree from the current board.
+func (c *ChessGame) newTree() {
+	c.startBoard = c.board
+	c.startWhiteToMove = c.whiteToMove
+	c.startCastling = c.castling
+	c.startEnPassant = c.enPassant
+	c.root = &GameNode{}
+	c.current = c.root
+	c.nodeCount = 1
+}
+
+// addMove records a move played from the current node and makes it current.
+// Playing a move that already exists below the current node reuses it.
+func (c *ChessGame) addMove(move Move) *GameNode {
+	for _, child := range c.current.children {
+		if child.move.fromRow == move.fromRow && child.move.fromCol == move.fromCol &&
+			child.move.toRow == move.toRow && child.move.toCol == move.toCol {
+			c.current.selected = child
+			c.current = child
+			return child
+		}
+	}
+
+	node := &GameNode{id: c.nodeCount, ply: c.current.ply + 1, move: move, parent: c.current}
+	c.nodeCount++
+	c.current.children = append(c.current.children, node)
+	c.current.selected = node
+	c.current = node
+	return node
+}
+
+// line returns the moves from the start of the game to the current node.
+func (c *ChessGame) line() []Move {
+	moves := make([]Move, c.current.ply)
+	for n := c.current; n.parent != nil; n = n.parent {
+		moves[n.ply-1] = n.move
+	}
+	return moves
+}
+
+func (c *ChessGame) applyMove(move Move) {
+	c.board[move.toRow][move.toCol] = c.board[move.fromRow][move.fromCol]
+	if move.promotion != "" {
+		c.board[move.toRow][move.toCol] = move.promotion
+	}
+	c.board[move.fromRow][move.fromCol] = ""
+	if move.enPassant {
+		c.board[move.fromRow][move.toCol] = ""
+	}
+	if isCastling(move) {
+		from, to := castlingRook(move.toCol)
+		c.board[move.toRow][to] = c.board[move.toRow][from]
+		c.board[move.toRow][from] = ""
+	}
+
+	c.castling = castlingRights(c.castling, move)
+	c.enPassant = ""
+	if strings.ToUpper(move.piece) == "P" && abs(move.toRow-move.fromRow) == 2 {
+		c.enPassant = squareName((move.fromRow+move.toRow)/2, move.fromCol)
+	}
+	c.whiteToMove = !c.whiteToMove
+}
+
+func (c *ChessGame) unapplyMove(move Move) {
+	c.board[move.fromRow][move.fromCol] = move.piece
+	c.board[move.toRow][move.toCol] = move.capturedPiece
+	if move.enPassant {
+		c.board[move.toRow][move.toCol] = ""
+		c.board[move.fromRow][move.toCol] = move.capturedPiece
+	}
+	if isCastling(move) {
+		from, to := castlingRook(move.toCol)
+		c.board[move.toRow][from] = c.board[move.toRow][to]
+		c.board[move.toRow][to] = ""
+	}
+	c.castling, c.enPassant = move.prevCastling, move.prevEnPassant
+	c.whiteToMove = !c.whiteToMove
+}
+
+// takeBack undoes the last move and deletes it, with everything played
+// after it, from the game tree.
+func (c *ChessGame) takeBack() {
+	node := c.current
+	if c.undoMove() != nil {
+		return
+	}
+	siblings := c.current.children
+	for i, sibling := range siblings {
+		if sibling == node {
+			c.current.children = append(siblings[:i:i], siblings[i+1:]...)
+			break
+		}
+	}
+	c.current.selected = nil
+}
+
+func (c *ChessGame) findNode(id int) *GameNode {
+	var walk func(n *GameNode) *GameNode
+	walk = func(n *GameNode) *GameNode {
+		if n.id == id {
+			return n
+		}
+		for _, child := range n.children {
+			if found := walk(child); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return walk(c.root)
+}
+
+// jumpTo replays the game from the start up to the node with the given id.
+func (c *ChessGame) jumpTo(id int) error {
+	node := c.findNode(id)
+	if node == nil {
+		return fmt.Errorf("no node %d", id)
+	}
+
+	c.board = c.startBoard
+	c.whiteToMove = c.startWhiteToMove
+	c.castling = c.startCastling
+	c.enPassant = c.startEnPassant
+	c.current = node
+	for _, move := range c.line() {
+		c.applyMove(move)
+	}
+	for n := node; n.parent != nil; n = n.parent {
+		n.parent.selected = n
+	}
+	c.updateResult()
+	return nil
+}
+
+// branch follows the n-th continuation (1 is the main line) from the
+// current node.
+func (c *ChessGame) branch(n int) error {
+	if n < 1 || n > len(c.current.children) {
+		return fmt.Errorf("no variation %d here", n)
+	}
+	child := c.current.children[n-1]
+	c.current.selected = child
+	c.current = child
+	c.applyMove(child.move)
+	c.updateResult()
+	return nil
+}
+
+// promote makes the line leading to the current node the main line, or
+// says why there is nothing to promote.
+func (c *ChessGame) promote() error {
+	if c.current.parent == nil {
+		return fmt.Errorf("at the start of the game there is no line to promote")
+	}
+	changed := false
+	for n := c.current; n.parent != nil; n = n.parent {
+		siblings := n.parent.children
+		for i, sibling := range siblings {
+			if sibling == n && i > 0 {
+				copy(siblings[1:i+1], siblings[:i])
+				siblings[0] = n
+				changed = true
+				break
+			}
+		}
+	}
+	if !changed {
+		return fmt.Errorf("the current line is already the main line")
+	}
+	return nil
+}
+
+// variationsText lists the continuations from the current node followed by
+// the whole tree with node ids, the current node marked with a star.
+func (c *ChessGame) variationsText() string {
+	var sb strings.Builder
+	if len(c.current.children) == 0 {
+		sb.WriteString("No continuations from here.\n")
+	}
+	for i, child := range c.current.children {
+		label := "variation"
+		if i == 0 {
+			label = "main line"
+		}
+		fmt.Fprintf(&sb, "%d. %s (%s, node %d)\n", i+1, c.moveLabel(child, true), label, child.id)
+	}
+
+	sb.WriteString("Tree: ")
+	c.writeVariation(&sb, c.root, true, func(n *GameNode) string {
+		if n == c.current {
+			return fmt.Sprintf("[%d*]", n.id)
+		}
+		return fmt.Sprintf("[%d]", n.id)
+	})
+	if c.current == c.root {
+		sb.WriteString(" [0*]")
+	}
+	return sb.String()
+}
+
+// moveLabel formats a node's move with its number, e.g. "12. Nf3" or
+// "12... Nc6". Black moves only get a number when forceNumber is set.
+func (c *ChessGame) moveLabel(n *GameNode, forceNumber bool) string {
+	offset := 0
+	if !c.startWhiteToMove {
+		offset = 1
+	}
+	number := (n.ply-1+offset)/2 + 1
+	switch {
+	case isWhitePiece(n.move.piece):
+		return fmt.Sprintf("%d. %s", number, n.move.annotatedSAN())
+	case forceNumber:
+		return fmt.Sprintf("%d... %s", number, n.move.annotatedSAN())
+	default:
+		return n.move.annotatedSAN()
+	}
+}
+
+// writeVariation writes the moves below node in PGN movetext style, with
+// variations in parentheses. suffix, if set, is appended to every move.
+func (c *ChessGame) writeVariation(sb *strings.Builder, node *GameNode, forceNumber bool, suffix func(*GameNode) string) {
+	writeMove := func(n *GameNode, forceNumber bool) {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "(") && !strings.HasSuffix(sb.String(), " ") {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(c.moveLabel(n, forceNumber))
+		if suffix != nil {
+			if text := suffix(n); text != "" {
+				sb.WriteString(" " + text)
+			}
+		}
+	}
+
+	for len(node.children) > 0 {
+		main := node.children[0]
+		writeMove(main, forceNumber)
+		for _, alt := range node.children[1:] {
+			sb.WriteString(" (")
+			writeMove(alt, true)
+			c.writeVariation(sb, alt, false, suffix)
+			sb.WriteByte(')')
+		}
+		forceNumber = len(node.children) > 1
+		node = main
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+// zobristKeys holds one random key per piece and square plus one for the
+// side to move. The keys are generated from a fixed seed so that hashes
+// stored in the game database stay valid between runs.
+var zobristKeys, zobristBlackToMove = makeZobristKeys()
+
+const zobristPieces = "PNBRQKpnbrqk"
+
+func makeZobristKeys() ([len(zobristPieces)][boardSize * boardSize]uint64, uint64) {
+	// splitmix64, so the keys do not depend on math/rand's generator.
+	state := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+
+	var keys [len(zobristPieces)][boardSize * boardSize]uint64
+	for p := range keys {
+		for sq := range keys[p] {
+			keys[p][sq] = next()
+		}
+	}
+	return keys, next()
+}
+
+// zobrist hashes the current position: the pieces and the side to move.
+func (c *ChessGame) zobrist() uint64 {
+	var hash uint64
+	for row := 0; row < boardSize; row++ {
+		for col := 0; col < boardSize; col++ {
+			piece := c.board[row][col]
+			if piece == "" {
+				continue
+			}
+			for p := 0; p < len(zobristPieces); p++ {
+				if zobristPieces[p] == piece[0] {
+					hash ^= zobristKeys[p][row*boardSize+col]
+					break
+				}
+			}
+		}
+	}
+	if !c.whiteToMove {
+		hash ^= zobristBlackToMove
+	}
+	return hash
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pilot steers a snake in place of the keyboard.
+type Pilot interface {
+	Steer(g *Game, s *Snake) Direction
+}
+
+// PilotKind names an autopilot strategy.
+type PilotKind string
+
+const (
+	PathKind  PilotKind = "path"  // shortest path to the food, chasing the tail when that is unsafe
+	CycleKind PilotKind = "cycle" // a Hamiltonian cycle over the whole arena
+)
+
+func parsePilot(s string) (PilotKind, error) {
+	switch kind := PilotKind(strings.ToLower(s)); kind {
+	case PathKind, CycleKind:
+		return kind, nil
+	}
+	return "", fmt.Errorf("unknown autopilot %q (want path or cycle)", s)
+}
+
+// newPilot returns the strategy of the given kind for snake s. The cycle
+// needs an arena without walls or portals and with an even side, so the
+// path finder stands in when the level has no Hamiltonian cycle.
+func (g *Game) newPilot(kind PilotKind, s *Snake) Pilot {
+	if kind != CycleKind || len(g.level.Walls) > 0 || len(g.level.Portals) > 0 {
+		return &PathPilot{}
+	}
+	cycle := hamiltonianCycle(g.width, g.height)
+	if cycle == nil {
+		return &PathPilot{}
+	}
+	p := &CyclePilot{cycle: cycle, order: map[Coord]int{}}
+	for i, c := range cycle {
+		p.order[c] = i
+	}
+	// Run the cycle the way the snake is already heading, so it does not
+	// have to turn around before it can join.
+	if p.next(s.body[0]) == s.body[1] {
+		for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+			cycle[i], cycle[j] = cycle[j], cycle[i]
+		}
+		for i, c := range cycle {
+			p.order[c] = i
+		}
+	}
+	return p
+}
+
+// togglePilot hands player one's snake between the keyboard and the
+// autopilot.
+func (g *Game) togglePilot() {
+	s := g.snakes[0]
+	s.turns.Reset()
+	if s.pilot != nil {
+		s.pilot = nil
+		return
+	}
+	s.pilot = g.newPilot(g.pilotKind, s)
+	s.assisted = true
+}
+
+var directions = []Direction{Up, Right, Down, Left}
+
+// move is one step of a planned path.
+type move struct {
+	dir Direction
+	to  Coord
+}
+
+// occupancy maps the cells in the way to the number of ticks until they
+// are free. Segment i of body moves on once the tail has passed it,
+// len(body)-i ticks from now; the other snakes never do as far as the
+// autopilot is concerned.
+func (g *Game) occupancy(body []Coord, others map[Coord]bool) map[Coord]int {
+	busy := map[Coord]int{}
+	for c := range others {
+		busy[c] = g.width * g.height
+	}
+	for i, c := range body {
+		busy[c] = len(body) - i
+	}
+	return busy
+}
+
+// search finds the shortest path from a cell to one that satisfies goal,
+// only entering a cell once it is free. It returns nil when there is none.
+func (g *Game) search(from Coord, busy map[Coord]int, goal func(Coord) bool) []move {
+	prev := map[Coord]move{}
+	seen := map[Coord]bool{from: true}
+	queue := []Coord{from}
+	for t := 1; len(queue) > 0; t++ {
+		var next []Coord
+		for _, p := range queue {
+			for _, d := range directions {
+				c, ok := g.step(p, d)
+				if !ok || seen[c] || busy[c] > t {
+					continue
+				}
+				seen[c] = true
+				prev[c] = move{dir: d, to: p}
+				if goal(c) {
+					var path []move
+					for ; c != from; c = prev[c].to {
+						path = append([]move{{dir: prev[c].dir, to: c}}, path...)
+					}
+					return path
+				}
+				next = append(next, c)
+			}
+		}
+		queue = next
+	}
+	return nil
+}
+
+// space counts the free cells reachable from a cell.
+func (g *Game) space(from Coord, busy map[Coord]int) int {
+	seen := map[Coord]bool{from: true}
+	queue := []Coord{from}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range directions {
+			if c, ok := g.step(p, d); ok && !seen[c] && busy[c] == 0 {
+				seen[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+	return len(seen)
+}
+
+// obstacles returns the cells taken by every snake other than s.
+func (g *Game) obstacles(s *Snake) map[Coord]bool {
+	blocked := map[Coord]bool{}
+	for _, other := range g.snakes {
+		if other != s {
+			for _, c := range other.body {
+				blocked[c] = true
+			}
+		}
+	}
+	return blocked
+}
+
+// escape returns how many moves it takes the head of body to catch up with
+// a segment the tail has moved past, after which the snake can keep
+// following its tail, or -1 when it cannot. When every segment in reach is
+// still in the way, a snake allowed to wander can wait for one by roaming
+// the open cells it can reach, if there are enough of them. That is a
+// gamble, since the snake may box itself in on the way.
+func (g *Game) escape(body []Coord, others map[Coord]bool, wander bool) int {
+	segment := map[Coord]bool{}
+	for _, c := range body[1:] {
+		segment[c] = true
+	}
+	busy := g.occupancy(body, others)
+	if path := g.search(body[0], busy, func(c Coord) bool { return segment[c] }); path != nil {
+		return len(path)
+	}
+	if !wander {
+		return -1
+	}
+
+	wait := -1
+	seen := map[Coord]bool{body[0]: true}
+	queue := []Coord{body[0]}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, d := range directions {
+			c, ok := g.step(p, d)
+			switch {
+			case !ok || seen[c] || others[c]:
+			case segment[c]:
+				if wait < 0 || busy[c] < wait {
+					wait = busy[c]
+				}
+			default:
+				seen[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+	if wait < 0 || wait > len(seen) {
+		return -1
+	}
+	return wait
+}
+
+// foodDistance is the number of moves from the head of body to the nearest
+// food, or more than any path can take when there is no way there.
+func (g *Game) foodDistance(body []Coord, others map[Coord]bool) int {
+	if g.foodAt(body[0]) != nil {
+		return 0
+	}
+	if path := g.search(body[0], g.occupancy(body, others), g.hasFood); path != nil {
+		return len(path)
+	}
+	return g.width * g.height
+}
+
+func (g *Game) hasFood(c Coord) bool {
+	return g.foodAt(c) != nil
+}
+
+// PathPilot heads for the nearest food along the shortest path, but only
+// when the snake could still follow its own tail after eating. Otherwise it
+// circles towards the food while keeping its tail in reach. Circling can
+// keep the food walled in by the body for good, so once the snake has gone
+// a whole arena's worth of ticks without eating it takes the long way round
+// to change its shape, and takes chances on reaching the food.
+type PathPilot struct {
+	score  int
+	hungry int // ticks since the score last changed
+}
+
+func (p *PathPilot) Steer(g *Game, s *Snake) Direction {
+	if s.score != p.score {
+		p.score, p.hungry = s.score, 0
+	}
+	p.hungry++
+	hungry := p.hungry > g.width*g.height
+
+	others := g.obstacles(s)
+	if path := g.search(s.body[0], g.occupancy(s.body, others), g.hasFood); path != nil {
+		body := append([]Coord{}, s.body...)
+		for i, m := range path {
+			body = append([]Coord{m.to}, body...)
+			if i < len(path)-1 {
+				body = body[:len(body)-1]
+			}
+		}
+		if g.escape(body, others, hungry) >= 0 {
+			return path[0].dir
+		}
+	}
+	return g.chaseTail(s, others, hungry)
+}
+
+// chaseTail picks the move to make when heading straight for the food is
+// not safe. Of the moves that keep the tail in reach it takes the one
+// nearest the food, or the longest way round to the tail when the snake is
+// hungry, and then the one with the most room. With no such move it takes
+// the one with the most room.
+func (g *Game) chaseTail(s *Snake, others map[Coord]bool, hungry bool) Direction {
+	best, bestSafe, bestRank, bestSpace := s.dir, false, 0, -1
+	for _, d := range directions {
+		next, ok := g.step(s.body[0], d)
+		if !ok || others[next] || (s.Contains(next) && next != s.body[len(s.body)-1]) {
+			continue
+		}
+		body := append([]Coord{next}, s.body...)
+		if !g.hasFood(next) {
+			body = body[:len(body)-1]
+		}
+		tail, space := g.escape(body, others, false), g.space(next, g.occupancy(body, others))
+		safe, rank := tail >= 0, 0
+		switch {
+		case safe && hungry:
+			rank = -tail
+		case safe:
+			rank = g.foodDistance(body, others)
+		}
+		if safe != bestSafe {
+			if !safe {
+				continue
+			}
+		} else if rank > bestRank || (rank == bestRank && space <= bestSpace) {
+			continue
+		}
+		best, bestSafe, bestRank, bestSpace = d, safe, rank, space
+	}
+	return best
+}
+
+// CyclePilot runs along a Hamiltonian cycle, a closed path through every
+// cell of the arena. Once the body lies along the cycle the head only ever
+// moves into the cell the tail has left or free floor, so the snake fills
+// the board without crashing. Until it can join the cycle safely it is
+// steered by the path finder.
+type CyclePilot struct {
+	cycle []Coord
+	order map[Coord]int
+	path  PathPilot
+}
+
+func (p *CyclePilot) next(c Coord) Coord {
+	return p.cycle[(p.order[c]+1)%len(p.cycle)]
+}
+
+func (p *CyclePilot) Steer(g *Game, s *Snake) Direction {
+	head := s.body[0]
+	next := p.next(head)
+	if !g.obstacles(s)[next] && p.canFollow(s) {
+		return directionTo(head, next)
+	}
+	return p.path.Steer(g, s)
+}
+
+// canFollow reports whether the snake can keep to the cycle from here:
+// either its body already lies along the cycle, or every segment will
+// have moved away before the head gets round to its cell. One tick of
+// slack covers the snake growing on the way.
+func (p *CyclePilot) canFollow(s *Snake) bool {
+	n, head := len(p.cycle), p.order[s.body[0]]
+	aligned := true
+	for j, c := range s.body {
+		if p.order[c] != (head-j+n)%n {
+			aligned = false
+			break
+		}
+	}
+	if aligned {
+		return true
+	}
+	for j, c := range s.body[1:] {
+		ahead := (p.order[c] - head + n) % n
+		if ahead <= len(s.body)-(j+1)+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// hamiltonianCycle returns a cycle through every cell of a width x height
+// grid, or nil when both sides are odd and there is none. With an even
+// height it runs right and left along the rows, leaving column 0 for the
+// way back up; with an odd height it does the same along the columns.
+func hamiltonianCycle(width, height int) []Coord {
+	if height%2 != 0 {
+		if width%2 != 0 {
+			return nil
+		}
+		cycle := hamiltonianCycle(height, width)
+		for i, c := range cycle {
+			cycle[i] = Coord{c.y, c.x}
+		}
+		return cycle
+	}
+	cycle := []Coord{{0, 0}}
+	for y := 0; y < height; y++ {
+		for x := 1; x < width; x++ {
+			if y%2 == 0 {
+				cycle = append(cycle, Coord{x, y})
+			} else {
+				cycle = append(cycle, Coord{width - x, y})
+			}
+		}
+	}
+	for y := height - 1; y > 0; y-- {
+		cycle = append(cycle, Coord{0, y})
+	}
+	return cycle
+}
+
+// directionTo is the direction of a step between two neighbouring cells.
+func directionTo(from, to Coord) Direction {
+	switch {
+	case to.x > from.x:
+		return Right
+	case to.x < from.x:
+		return Left
+	case to.y > from.y:
+		return Down
+	}
+	return Up
+}
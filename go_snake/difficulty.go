@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpeedStep sets the tick interval once the score reaches MinScore.
+type SpeedStep struct {
+	MinScore int           `json:"min_score"`
+	Interval time.Duration `json:"interval"`
+}
+
+// Difficulty maps the score to the tick interval through a curve of steps
+// sorted by MinScore.
+type Difficulty struct {
+	Name  string      `json:"name"`
+	Curve []SpeedStep `json:"curve"`
+}
+
+var presets = []Difficulty{
+	{Name: "easy", Curve: []SpeedStep{
+		{0, 700 * time.Millisecond},
+		{5, 600 * time.Millisecond},
+		{10, 500 * time.Millisecond},
+		{20, 400 * time.Millisecond},
+		{30, 300 * time.Millisecond},
+	}},
+	{Name: "normal", Curve: []SpeedStep{
+		{0, 700 * time.Millisecond},
+		{3, 600 * time.Millisecond},
+		{6, 555 * time.Millisecond},
+		{11, 400 * time.Millisecond},
+		{16, 300 * time.Millisecond},
+		{21, 200 * time.Millisecond},
+		{31, 100 * time.Millisecond},
+	}},
+	{Name: "insane", Curve: []SpeedStep{
+		{0, 150 * time.Millisecond},
+		{10, 100 * time.Millisecond},
+		{20, 70 * time.Millisecond},
+		{30, 50 * time.Millisecond},
+	}},
+}
+
+func presetNames() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func findPreset(name string) (Difficulty, error) {
+	for _, p := range presets {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return Difficulty{}, fmt.Errorf("unknown difficulty %q (want %s)", name, strings.Join(presetNames(), ", "))
+}
+
+// parseCurve reads a custom curve written as comma-separated score:milliseconds
+// pairs, such as "0:300,10:200,25:120".
+func parseCurve(s string) (Difficulty, error) {
+	d := Difficulty{Name: "custom"}
+	for _, field := range strings.Split(s, ",") {
+		score, ms, ok := strings.Cut(strings.TrimSpace(field), ":")
+		if !ok {
+			return Difficulty{}, fmt.Errorf("curve step %q: want score:milliseconds", field)
+		}
+		minScore, err := strconv.Atoi(score)
+		if err != nil || minScore < 0 {
+			return Difficulty{}, fmt.Errorf("curve step %q: bad score", field)
+		}
+		interval, err := strconv.Atoi(ms)
+		if err != nil || interval <= 0 {
+			return Difficulty{}, fmt.Errorf("curve step %q: bad interval", field)
+		}
+		d.Curve = append(d.Curve, SpeedStep{minScore, time.Duration(interval) * time.Millisecond})
+	}
+	sort.Slice(d.Curve, func(i, j int) bool { return d.Curve[i].MinScore < d.Curve[j].MinScore })
+	for i := 1; i < len(d.Curve); i++ {
+		if d.Curve[i].MinScore == d.Curve[i-1].MinScore {
+			return Difficulty{}, fmt.Errorf("curve has two steps for score %d", d.Curve[i].MinScore)
+		}
+	}
+	return d, nil
+}
+
+// Interval returns the tick interval for a score: the last step whose
+// MinScore has been reached, or the first step below it.
+func (d Difficulty) Interval(score int) time.Duration {
+	interval := d.Curve[0].Interval
+	for _, step := range d.Curve {
+		if score < step.MinScore {
+			break
+		}
+		interval = step.Interval
+	}
+	return interval
+}
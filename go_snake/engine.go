@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/eiannone/keyboard"
+)
+
+// Clock is the game's time source. Tests use one they tick by hand.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Input is where a game's key presses and resize notices come from: the
+// keyboard and the terminal when playing, or channels a test fills.
+type Input struct {
+	Keys    <-chan keyboard.KeyEvent
+	Resized <-chan os.Signal
+}
+
+// Frame tells the rendering layer what a step did to the picture.
+type Frame int
+
+const (
+	Unchanged   Frame = iota
+	Changed           // draw the frame again
+	Invalidated       // the terminal was resized, so redraw every cell
+)
+
+// Step waits for the next key press, resize or tick and applies it,
+// without drawing anything. Keys open and close overlays or queue turns,
+// and the clock stops while an overlay is shown. A tick moves the snakes
+// and returns how the game stands; until then the game is Playing.
+func (g *Game) Step(in Input, clock Clock) (Outcome, Frame) {
+	if g.nextTick == nil && g.overlay == NoOverlay {
+		g.speed = g.tickInterval()
+		g.startClock(clock, g.speed)
+	}
+
+	select {
+	case ev, ok := <-in.Keys:
+		if !ok || ev.Err != nil || isQuitKey(ev) || g.demo {
+			return Quit, Unchanged
+		}
+		before := g.overlay
+		g.handleKey(ev)
+		switch {
+		case before == NoOverlay && g.overlay != NoOverlay:
+			g.stopClock(clock)
+		case before != NoOverlay && g.overlay == NoOverlay:
+			g.startClock(clock, g.remaining)
+		}
+		if before != g.overlay {
+			return Playing, Changed
+		}
+		return Playing, Unchanged
+	case <-in.Resized:
+		if g.demo {
+			return Quit, Unchanged
+		}
+		// The terminal may have cut off part of the arena, so wait for the
+		// player before the next tick.
+		if g.overlay == NoOverlay {
+			g.stopClock(clock)
+		}
+		g.overlay = ResizeOverlay
+		return Playing, Invalidated
+	case <-g.nextTick:
+		g.nextTick = nil
+		return g.Update(), Changed
+	}
+}
+
+// startClock schedules the next tick d from now.
+func (g *Game) startClock(clock Clock, d time.Duration) {
+	g.deadline = clock.Now().Add(d)
+	g.nextTick = clock.After(d)
+}
+
+// stopClock holds the next tick back, keeping the time that was left.
+func (g *Game) stopClock(clock Clock) {
+	g.remaining = g.deadline.Sub(clock.Now())
+	g.nextTick = nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eiannone/keyboard"
+)
+
+// manualClock only ticks when the test says so.
+type manualClock struct {
+	now   time.Time
+	ticks chan time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{now: time.Unix(0, 0), ticks: make(chan time.Time, 1)}
+}
+
+func (c *manualClock) Now() time.Time                         { return c.now }
+func (c *manualClock) After(d time.Duration) <-chan time.Time { return c.ticks }
+func (c *manualClock) tick()                                  { c.ticks <- c.now }
+
+// newTestGame returns a 20x10 single-player game with the snake laid out
+// as body and heading in dir. The food is off the board unless the test
+// places it.
+func newTestGame(mode Mode, level *Level, body []Coord, dir Direction) *Game {
+	g := NewGame(Settings{Difficulty: presets[0], Mode: mode, Level: level, Players: 1}, 20, 10, 1)
+	s := g.snakes[0]
+	s.body, s.dir = body, dir
+	g.food.pos = Coord{-1, -1}
+	g.special = nil
+	return g
+}
+
+// play presses each key in turn and then plays one tick.
+func play(t *testing.T, g *Game, keys ...keyboard.KeyEvent) Outcome {
+	t.Helper()
+	pressed := make(chan keyboard.KeyEvent, len(keys))
+	in := Input{Keys: pressed}
+	clock := newManualClock()
+	for _, key := range keys {
+		pressed <- key
+		if outcome, frame := g.Step(in, clock); outcome != Playing || frame != Unchanged {
+			t.Fatalf("key %q: got %v, %v; want a queued turn", key.Rune, outcome, frame)
+		}
+	}
+	clock.tick()
+	outcome, frame := g.Step(in, clock)
+	if frame != Changed {
+		t.Fatalf("tick: frame %v, want Changed", frame)
+	}
+	return outcome
+}
+
+func key(r rune) keyboard.KeyEvent {
+	return keyboard.KeyEvent{Rune: r}
+}
+
+var straight = []Coord{{10, 5}, {11, 5}, {12, 5}}
+
+func TestStepMovement(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []keyboard.KeyEvent
+		want []Coord
+	}{
+		{"straight on", nil, []Coord{{9, 5}, {10, 5}, {11, 5}}},
+		{"up", []keyboard.KeyEvent{key('w')}, []Coord{{10, 4}, {10, 5}, {11, 5}}},
+		{"down by arrow", []keyboard.KeyEvent{{Key: keyboard.KeyArrowDown}}, []Coord{{10, 6}, {10, 5}, {11, 5}}},
+		{"reversal ignored", []keyboard.KeyEvent{key('d')}, []Coord{{9, 5}, {10, 5}, {11, 5}}},
+		{"one turn per tick", []keyboard.KeyEvent{key('w'), key('d')}, []Coord{{10, 4}, {10, 5}, {11, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(Walls, openLevel, append([]Coord{}, straight...), Left)
+			if outcome := play(t, g, tt.keys...); outcome != Playing {
+				t.Fatalf("outcome %v, want Playing", outcome)
+			}
+			body := g.snakes[0].body
+			if len(body) != len(tt.want) {
+				t.Fatalf("body %v, want %v", body, tt.want)
+			}
+			for i := range body {
+				if body[i] != tt.want[i] {
+					t.Fatalf("body %v, want %v", body, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStepGrowth(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       FoodKind
+		wantLength int
+		wantScore  int
+	}{
+		{"food", NormalFood, 4, 1},
+		{"bonus", BonusFood, 4, 5},
+		{"shrink pill keeps the starting length", ShrinkPill, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(Walls, openLevel, append([]Coord{}, straight...), Left)
+			if tt.kind == NormalFood {
+				g.food.pos = Coord{9, 5}
+			} else {
+				g.special = &Food{pos: Coord{9, 5}, kind: tt.kind, ticksLeft: 10}
+			}
+			if outcome := play(t, g); outcome != Playing {
+				t.Fatalf("outcome %v, want Playing", outcome)
+			}
+			s := g.snakes[0]
+			if len(s.body) != tt.wantLength || s.score != tt.wantScore {
+				t.Errorf("length %d, score %d; want %d, %d", len(s.body), s.score, tt.wantLength, tt.wantScore)
+			}
+			if g.foodAt(Coord{9, 5}) != nil {
+				t.Errorf("the food was not eaten")
+			}
+		})
+	}
+}
+
+func TestStepCollisions(t *testing.T) {
+	walled := &Level{Name: "walled", Walls: map[Coord]bool{{9, 5}: true}, Portals: map[Coord]Coord{}}
+	tests := []struct {
+		name     string
+		mode     Mode
+		level    *Level
+		body     []Coord
+		dir      Direction
+		keys     []keyboard.KeyEvent
+		want     Outcome
+		wantHead Coord
+	}{
+		{"left edge", Walls, openLevel, []Coord{{0, 5}, {1, 5}, {2, 5}}, Left, nil, HitWall, Coord{0, 5}},
+		{"top edge", Walls, openLevel, []Coord{{5, 0}, {5, 1}, {5, 2}}, Up, nil, HitWall, Coord{5, 0}},
+		{"wraps in portals mode", Portals, openLevel, []Coord{{0, 5}, {1, 5}, {2, 5}}, Left, nil, Playing, Coord{19, 5}},
+		{"level wall", Portals, walled, append([]Coord{}, straight...), Left, nil, HitWall, Coord{10, 5}},
+		{"own body", Walls, openLevel, []Coord{{5, 5}, {6, 5}, {6, 4}, {5, 4}, {4, 4}}, Left, []keyboard.KeyEvent{key('w')}, HitSelf, Coord{5, 4}},
+		{"tail moves away", Walls, openLevel, []Coord{{5, 5}, {6, 5}, {6, 4}, {5, 4}}, Left, []keyboard.KeyEvent{key('w')}, Playing, Coord{5, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(tt.mode, tt.level, tt.body, tt.dir)
+			if outcome := play(t, g, tt.keys...); outcome != tt.want {
+				t.Errorf("outcome %v, want %v", outcome, tt.want)
+			}
+			if head := g.snakes[0].body[0]; head != tt.wantHead {
+				t.Errorf("head at %v, want %v", head, tt.wantHead)
+			}
+		})
+	}
+}
+
+func TestStepOverlayStopsClock(t *testing.T) {
+	g := newTestGame(Walls, openLevel, append([]Coord{}, straight...), Left)
+	pressed := make(chan keyboard.KeyEvent, 2)
+	in, clock := Input{Keys: pressed}, newManualClock()
+
+	pressed <- key('p')
+	if outcome, frame := g.Step(in, clock); outcome != Playing || frame != Changed {
+		t.Fatalf("pause: got %v, %v", outcome, frame)
+	}
+	if g.overlay != PauseOverlay || g.nextTick != nil {
+		t.Fatalf("pause: overlay %v, clock running %v", g.overlay, g.nextTick != nil)
+	}
+
+	pressed <- key('q')
+	if outcome, _ := g.Step(in, clock); outcome != Quit {
+		t.Fatalf("quit: got %v", outcome)
+	}
+	if head := g.snakes[0].body[0]; head != straight[0] {
+		t.Errorf("the snake moved while paused, to %v", head)
+	}
+}
+
+func TestGenerateFood(t *testing.T) {
+	walls := map[Coord]bool{}
+	for x := 0; x < 20; x++ {
+		walls[Coord{x, 0}] = true
+	}
+	level := &Level{Name: "top wall", Walls: walls, Portals: map[Coord]Coord{{3, 3}: {15, 7}, {15, 7}: {3, 3}}}
+
+	for seed := int64(1); seed <= 100; seed++ {
+		g := NewGame(Settings{Difficulty: presets[0], Mode: Walls, Level: level, Players: 1}, 20, 10, seed)
+		for i := 0; i < 10; i++ {
+			g.GenerateFood()
+			p := g.food.pos
+			if p.x < 0 || p.x >= g.width || p.y < 0 || p.y >= g.height {
+				t.Fatalf("seed %d: food off the board at %v", seed, p)
+			}
+			if snake, _ := g.snakeAt(p); snake != nil || level.Walls[p] || level.isPortal(p) {
+				t.Fatalf("seed %d: food placed on a taken cell %v", seed, p)
+			}
+		}
+	}
+
+	a := NewGame(Settings{Difficulty: presets[0], Mode: Walls, Level: openLevel, Players: 1}, 20, 10, 42)
+	b := NewGame(Settings{Difficulty: presets[0], Mode: Walls, Level: openLevel, Players: 1}, 20, 10, 42)
+	for i := 0; i < 10; i++ {
+		if a.food.pos != b.food.pos {
+			t.Fatalf("the same seed placed food at %v and %v", a.food.pos, b.food.pos)
+		}
+		a.GenerateFood()
+		b.GenerateFood()
+	}
+}
+
+func TestGenerateFoodFullBoard(t *testing.T) {
+	g := newTestGame(Walls, openLevel, nil, Left)
+	var body []Coord
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			body = append(body, Coord{x, y})
+		}
+	}
+
+	g.snakes[0].body = body[1:]
+	g.GenerateFood()
+	if g.food.pos != body[0] {
+		t.Errorf("food at %v, want the only free cell %v", g.food.pos, body[0])
+	}
+
+	g.snakes[0].body = body
+	g.GenerateFood()
+	if g.food.pos != (Coord{-1, -1}) {
+		t.Errorf("food at %v on a full board, want it off the board", g.food.pos)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type FoodKind int
+
+const (
+	NormalFood FoodKind = iota
+	BonusFood           // worth more, but only for a while
+	ShrinkPill          // takes segments off the tail
+	SlowMotion          // doubles the tick interval for a while
+	GhostPill           // lets the head pass through the body for a while
+)
+
+// foodSpec describes how a kind of food looks and what eating it does.
+type foodSpec struct {
+	name     string
+	glyph    string
+	style    string
+	points   int
+	grows    bool
+	lifetime int // ticks on the board before it disappears; 0 for never
+	effect   int // ticks the power-up lasts after eating it
+}
+
+var foodSpecs = map[FoodKind]foodSpec{
+	NormalFood: {name: "Food", glyph: "*", style: "\033[31m", points: 1, grows: true},
+	BonusFood:  {name: "Bonus", glyph: "$", style: "\033[1;33m", points: 5, grows: true, lifetime: 40},
+	ShrinkPill: {name: "Shrink", glyph: "%", style: "\033[36m", points: 1, lifetime: 60},
+	SlowMotion: {name: "Slow", glyph: "~", style: "\033[34m", points: 1, lifetime: 60, effect: 50},
+	GhostPill:  {name: "Ghost", glyph: "&", style: "\033[1;37m", points: 1, lifetime: 60, effect: 50},
+}
+
+// specialChance is the chance, as 1 in n, that eating normal food brings
+// out one of the special kinds.
+const specialChance = 4
+
+// shrinkBy is how many segments a shrink pill removes; the snake never gets
+// shorter than startLength, the length it starts with.
+const (
+	shrinkBy    = 3
+	startLength = 3
+)
+
+type Food struct {
+	pos       Coord
+	kind      FoodKind
+	ticksLeft int
+}
+
+func (f Food) spec() foodSpec {
+	return foodSpecs[f.kind]
+}
+
+// freeCell returns a random cell that is not on a snake, a wall, a portal
+// or other food.
+func (g *Game) freeCell() (Coord, bool) {
+	var free []Coord
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			p := Coord{x, y}
+			if snake, _ := g.snakeAt(p); snake == nil && !g.level.Walls[p] && !g.level.isPortal(p) && g.foodAt(p) == nil {
+				free = append(free, p)
+			}
+		}
+	}
+	if len(free) == 0 {
+		return Coord{}, false
+	}
+	return free[g.rng.Intn(len(free))], true
+}
+
+// GenerateFood puts the normal food on a random free cell. When there is no
+// free cell the food is taken off the board.
+func (g *Game) GenerateFood() {
+	g.food = Food{pos: Coord{-1, -1}, kind: NormalFood}
+	if p, ok := g.freeCell(); ok {
+		g.food.pos = p
+	}
+}
+
+// spawnSpecial sometimes places a bonus food or power-up, when none is on
+// the board.
+func (g *Game) spawnSpecial() {
+	if g.special != nil || g.rng.Intn(specialChance) != 0 {
+		return
+	}
+	p, ok := g.freeCell()
+	if !ok {
+		return
+	}
+	kind := FoodKind(1 + g.rng.Intn(len(foodSpecs)-1))
+	g.special = &Food{pos: p, kind: kind, ticksLeft: foodSpecs[kind].lifetime}
+}
+
+func (g *Game) foodAt(p Coord) *Food {
+	if g.food.pos == p {
+		return &g.food
+	}
+	if g.special != nil && g.special.pos == p {
+		return g.special
+	}
+	return nil
+}
+
+// eat applies the food snake s has just reached and reports whether it
+// grows. Power-ups only affect the snake that ate them.
+func (g *Game) eat(s *Snake, f *Food) bool {
+	spec := f.spec()
+	s.score += spec.points
+	switch f.kind {
+	case NormalFood:
+		g.GenerateFood()
+		g.spawnSpecial()
+	case ShrinkPill:
+		// The tail has not caught up with the head yet this tick, so the
+		// body is one segment longer than it will end the tick.
+		keep := len(s.body) - shrinkBy
+		if keep < startLength+1 {
+			keep = startLength + 1
+		}
+		if keep < len(s.body) {
+			s.body = s.body[:keep]
+		}
+	}
+	if spec.effect > 0 {
+		s.effects[f.kind] = spec.effect
+	}
+	if f.kind != NormalFood {
+		g.special = nil
+	}
+	return spec.grows
+}
+
+// tickFood counts down the power-ups and removes special food that has
+// been on the board too long.
+func (g *Game) tickFood() {
+	for _, s := range g.snakes {
+		for kind, left := range s.effects {
+			if left <= 1 {
+				delete(s.effects, kind)
+			} else {
+				s.effects[kind] = left - 1
+			}
+		}
+	}
+	if g.special != nil {
+		g.special.ticksLeft--
+		if g.special.ticksLeft <= 0 {
+			g.special = nil
+		}
+	}
+}
+
+// foodHUD describes the special food on the board and the active power-ups
+// with their remaining ticks.
+func (g *Game) foodHUD() string {
+	var parts []string
+	if g.special != nil {
+		spec := g.special.spec()
+		parts = append(parts, fmt.Sprintf("%s %s %d", spec.glyph, spec.name, g.special.ticksLeft))
+	}
+	for _, s := range g.snakes {
+		for _, kind := range []FoodKind{SlowMotion, GhostPill} {
+			if left := s.effects[kind]; left > 0 {
+				part := fmt.Sprintf("%s on %d", foodSpecs[kind].name, left)
+				if len(g.snakes) > 1 {
+					part = s.name + ": " + part
+				}
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, "  ")
+}
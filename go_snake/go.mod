@@ -1,7 +1,8 @@
-module main
+module go_snake
 
 go 1.20
 
-require github.com/eiannone/keyboard v0.0.0-20220611211555-0d226195f203
-
-require golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
+require (
+	github.com/eiannone/keyboard v0.0.0-20220611211555-0d226195f203
+	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a
+)
@@ -0,0 +1,103 @@
+package main
+
+import "github.com/eiannone/keyboard"
+
+type Direction string
+
+const (
+	Up    Direction = "up"
+	Down  Direction = "down"
+	Left  Direction = "left"
+	Right Direction = "right"
+)
+
+// maxQueuedTurns bounds how many key presses can be buffered ahead of the
+// snake, so a burst of keys is not replayed long after it was typed.
+const maxQueuedTurns = 3
+
+func (d Direction) opposite() Direction {
+	switch d {
+	case Up:
+		return Down
+	case Down:
+		return Up
+	case Left:
+		return Right
+	case Right:
+		return Left
+	}
+	return d
+}
+
+// DirectionQueue holds turns that have been typed but not yet applied.
+// The game takes one turn per tick, so quick key presses such as up then
+// left both happen instead of the second replacing the first.
+type DirectionQueue struct {
+	turns []Direction
+}
+
+// Push queues a turn. It is dropped when it repeats the direction the snake
+// will already be heading in, reverses it, or the queue is full.
+func (q *DirectionQueue) Push(current, d Direction) {
+	last := current
+	if len(q.turns) > 0 {
+		last = q.turns[len(q.turns)-1]
+	}
+	if d == last || d == last.opposite() || len(q.turns) >= maxQueuedTurns {
+		return
+	}
+	q.turns = append(q.turns, d)
+}
+
+// Next returns the direction for the coming tick.
+func (q *DirectionQueue) Next(current Direction) Direction {
+	if len(q.turns) == 0 {
+		return current
+	}
+	d := q.turns[0]
+	q.turns = q.turns[1:]
+	return d
+}
+
+func (q *DirectionQueue) Reset() {
+	q.turns = nil
+}
+
+// keyDirection maps WASD and the arrow keys to a direction.
+func keyDirection(ev keyboard.KeyEvent) (Direction, bool) {
+	switch {
+	case ev.Rune == 'w' || ev.Rune == 'W' || ev.Key == keyboard.KeyArrowUp:
+		return Up, true
+	case ev.Rune == 'a' || ev.Rune == 'A' || ev.Key == keyboard.KeyArrowLeft:
+		return Left, true
+	case ev.Rune == 's' || ev.Rune == 'S' || ev.Key == keyboard.KeyArrowDown:
+		return Down, true
+	case ev.Rune == 'd' || ev.Rune == 'D' || ev.Key == keyboard.KeyArrowRight:
+		return Right, true
+	}
+	return "", false
+}
+
+func isArrowKey(ev keyboard.KeyEvent) bool {
+	switch ev.Key {
+	case keyboard.KeyArrowUp, keyboard.KeyArrowDown, keyboard.KeyArrowLeft, keyboard.KeyArrowRight:
+		return true
+	}
+	return false
+}
+
+func isQuitKey(ev keyboard.KeyEvent) bool {
+	return ev.Key == keyboard.KeyEsc || ev.Key == keyboard.KeyCtrlC || ev.Rune == 'q' || ev.Rune == 'Q'
+}
+
+func isPauseKey(ev keyboard.KeyEvent) bool {
+	return ev.Key == keyboard.KeySpace || ev.Rune == 'p' || ev.Rune == 'P'
+}
+
+func isHelpKey(ev keyboard.KeyEvent) bool {
+	return ev.Rune == 'h' || ev.Rune == 'H'
+}
+
+func isPilotKey(ev keyboard.KeyEvent) bool {
+	return ev.Rune == 'i' || ev.Rune == 'I'
+}
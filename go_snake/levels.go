@@ -0,0 +1,203 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//go:embed levels/*.txt
+var builtinLevels embed.FS
+
+// Level is an arena layout. Level files are ASCII grids:
+//
+//	#    wall
+//	S    the snake's head at the start; the body trails off to the right
+//	T    player two's head in a two-player game; the body trails off to
+//	     the left. Without a T, player two starts opposite player one.
+//	0-9  portals: entering one digit comes out at the other cell with the
+//	     same digit
+//
+// Any other character is open floor. An optional first line starting with
+// ';' names the level; otherwise it is named after the file. The grid sets
+// the arena size, and the arena's edge still follows the chosen mode.
+type Level struct {
+	Name    string
+	Width   int // 0 for an open arena sized to the terminal
+	Height  int
+	Walls   map[Coord]bool
+	Portals map[Coord]Coord
+	Start   *Coord
+	Start2  *Coord
+}
+
+var openLevel = &Level{Name: "Open arena", Walls: map[Coord]bool{}, Portals: map[Coord]Coord{}}
+
+func parseLevel(name, text string) (*Level, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], ";") {
+		name = strings.TrimSpace(strings.TrimPrefix(lines[0], ";"))
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("level %s is empty", name)
+	}
+
+	level := &Level{Name: name, Height: len(lines), Walls: map[Coord]bool{}, Portals: map[Coord]Coord{}}
+	portalEnds := map[rune][]Coord{}
+	for y, line := range lines {
+		x := 0
+		for _, ch := range line {
+			p := Coord{x, y}
+			switch {
+			case ch == '#':
+				level.Walls[p] = true
+			case ch == 'S':
+				if level.Start != nil {
+					return nil, fmt.Errorf("level %s: more than one start", name)
+				}
+				level.Start = &p
+			case ch == 'T':
+				if level.Start2 != nil {
+					return nil, fmt.Errorf("level %s: more than one start for player two", name)
+				}
+				level.Start2 = &p
+			case ch >= '0' && ch <= '9':
+				portalEnds[ch] = append(portalEnds[ch], p)
+			}
+			x++
+		}
+		if x > level.Width {
+			level.Width = x
+		}
+	}
+	if level.Width < minWidth || level.Height < minHeight {
+		return nil, fmt.Errorf("level %s is %dx%d, smaller than the minimum %dx%d", name, level.Width, level.Height, minWidth, minHeight)
+	}
+
+	for digit, ends := range portalEnds {
+		if len(ends) != 2 {
+			return nil, fmt.Errorf("level %s: portal %c has %d ends, want 2", name, digit, len(ends))
+		}
+		level.Portals[ends[0]] = ends[1]
+		level.Portals[ends[1]] = ends[0]
+	}
+
+	for players := 1; players <= 2; players++ {
+		taken := map[Coord]bool{}
+		for i, start := range level.starts(level.Width, level.Height, players) {
+			for _, p := range startingBody(start, startDirection(i)) {
+				if p.x < 0 || p.x >= level.Width || level.Walls[p] || level.isPortal(p) || taken[p] {
+					return nil, fmt.Errorf("level %s: no room for player %d at the start", name, i+1)
+				}
+				taken[p] = true
+			}
+		}
+	}
+	return level, nil
+}
+
+// starts returns the head's starting cell for each player: the S and T
+// cells when the level has them, otherwise the middle of the arena for one
+// player, or two points mirrored through the middle for two.
+func (l *Level) starts(width, height, players int) []Coord {
+	var first Coord
+	switch {
+	case l.Start != nil:
+		first = *l.Start
+	case players == 1:
+		first = Coord{width / 2, height / 2}
+	default:
+		first = Coord{width / 2, height / 3}
+	}
+	if players == 1 {
+		return []Coord{first}
+	}
+	second := Coord{width - 1 - first.x, height - 1 - first.y}
+	if l.Start2 != nil {
+		second = *l.Start2
+	}
+	return []Coord{first, second}
+}
+
+// startDirection is the direction player i heads in at the start: player
+// one left and player two right, so they do not meet head-on at once.
+func startDirection(i int) Direction {
+	if i == 0 {
+		return Left
+	}
+	return Right
+}
+
+func (l *Level) isPortal(p Coord) bool {
+	_, ok := l.Portals[p]
+	return ok
+}
+
+// startingBody is a three-cell snake heading in direction dir, with the
+// body trailing behind the head.
+func startingBody(head Coord, dir Direction) []Coord {
+	dx := 1
+	if dir == Right {
+		dx = -1
+	}
+	return []Coord{head, {head.x + dx, head.y}, {head.x + 2*dx, head.y}}
+}
+
+func getLevelsDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "levels"
+	}
+	return filepath.Join(homeDir, ".local", "share", "go-snake", "levels")
+}
+
+// loadLevels returns the open arena, the built-in levels and the *.txt
+// levels in dir, in that order. A missing dir is not an error.
+func loadLevels(dir string) ([]*Level, error) {
+	levels := []*Level{openLevel}
+
+	builtin, err := builtinLevels.ReadDir("levels")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range builtin {
+		data, err := builtinLevels.ReadFile("levels/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		level, err := parseLevel(levelName(entry.Name()), string(data))
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		level, err := parseLevel(levelName(path), string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+func levelName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
@@ -1,20 +1,30 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
-	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/eiannone/keyboard"
 )
 
 type Snake struct {
+	name      string
+	style     string
 	body      []Coord
 	bodyShape string
 	head      string
+	dir       Direction
+	turns     DirectionQueue
+	score     int
+	effects   map[FoodKind]int
+	crash     Outcome // why the snake died, or Playing while it is alive
+	pilot     Pilot   // steers the snake instead of the keyboard, when set
+	assisted  bool    // the autopilot has steered this snake at some point
 }
 
 type Coord struct {
@@ -22,222 +32,546 @@ type Coord struct {
 	y int
 }
 
-type Food struct {
-	pos    Coord
-	symbol string
+// Mode decides what happens at the edge of the arena.
+type Mode string
+
+const (
+	Walls   Mode = "walls"   // the edge is a wall
+	Portals Mode = "portals" // the snake comes out on the opposite edge
+)
+
+func parseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(s)); mode {
+	case Walls, Portals:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown mode %q (want walls or portals)", s)
+}
+
+// Arena sizes in cells. The arena fills the terminal apart from the HUD
+// line and the border, but never gets smaller than the minimum.
+const (
+	defaultWidth  = 45
+	defaultHeight = 20
+	minWidth      = 20
+	minHeight     = 10
+	hudRows       = 2
+)
+
+// arenaSize returns the largest arena that fits the terminal.
+func arenaSize() (int, int) {
+	cols, rows, err := terminalSize()
+	if err != nil {
+		return defaultWidth, defaultHeight
+	}
+	width, height := cols-2, rows-hudRows-3
+	if width < minWidth {
+		width = minWidth
+	}
+	if height < minHeight {
+		height = minHeight
+	}
+	return width, height
 }
 
 type Game struct {
-	snake  Snake
-	food   Food
-	score  int
-	speed  time.Duration
-	width  int
-	height int
+	screen  *Screen
+	input   Input
+	clock   Clock
+	overlay Overlay
+	snakes  []*Snake
+	food    Food
+	special *Food
+	speed   time.Duration
+	width   int
+	height  int
+
+	difficulty Difficulty
+	mode       Mode
+	level      *Level
+
+	round int    // the round of a two-player match, for the HUD
+	wins  [2]int // rounds won by each player before this one
+
+	pilotKind PilotKind // the strategy the autopilot key hands control to
+	demo      bool      // an attract-mode game that any key ends
+
+	rng     *rand.Rand // places the food; seeded so a game can be replayed
+	seed    int64
+	tick    int     // ticks played so far
+	replay  *Replay // records the turns taken, when set
+	caption string  // shown on the second HUD line, such as replay controls
+
+	nextTick  <-chan time.Time // fires when the next tick is due; nil while the clock is stopped
+	deadline  time.Time
+	remaining time.Duration // time that was left to the next tick when the clock stopped
 }
 
-func NewGame() *Game {
-	g := &Game{
-		snake: Snake{
-			body:      []Coord{{10, 10}, {11, 10}, {12, 10}},
-			bodyShape: "-",
-			head:      "<",
-		},
-		food: Food{
-			pos:    Coord{15, 10},
-			symbol: " ",
-		},
-		score:  0,
-		speed:  700,
-		width:  45,
-		height: 20,
+// Outcome is the state of a game after a tick.
+type Outcome int
+
+const (
+	Playing Outcome = iota
+	HitWall
+	HitSelf
+	HitSnake  // ran into the other snake's body
+	HeadOn    // both heads met
+	RoundOver // at most one snake is left in a two-player round
+	Quit
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case HitWall:
+		return "You hit the wall!"
+	case HitSelf:
+		return "You ran into yourself!"
+	case HitSnake:
+		return "You ran into the other snake!"
+	case HeadOn:
+		return "Head-on crash!"
+	case RoundOver:
+		return "Round over"
+	case Quit:
+		return "Quit"
 	}
-	return g
+	return "Playing"
 }
 
-func (g *Game) Run() {
+// describe says how the named snake crashed, for two-player results.
+func (o Outcome) describe(name string) string {
+	switch o {
+	case HitWall:
+		return name + " hit the wall"
+	case HitSelf:
+		return name + " ran into itself"
+	case HitSnake:
+		return name + " ran into the other snake"
+	case HeadOn:
+		return name + " crashed head-on"
+	}
+	return name + " survived"
+}
+
+// Settings are the choices made before a game starts.
+type Settings struct {
+	Difficulty  Difficulty
+	Mode        Mode
+	Level       *Level
+	Players     int
+	RoundsToWin int // rounds a player needs to win a two-player match
+	Autopilot   PilotKind
+}
 
-	rand.Seed(time.Now().UnixNano())
+// Players are named and colored in the order of Game.snakes.
+var players = []struct{ name, style string }{
+	{"Player 1", "\033[32m"},
+	{"Player 2", "\033[33m"},
+}
 
-	dir := "left"
-	prevdir := "left"
+// NewGame starts a game on the settings' level with a snake for each
+// player. Levels with a fixed grid set their own size; the open arena uses
+// width and height. The same seed and settings always place the same food
+// for the same moves.
+func NewGame(settings Settings, width, height int, seed int64) *Game {
+	level := settings.Level
+	if level.Width > 0 {
+		width, height = level.Width, level.Height
+	}
+	g := &Game{
+		width:  width,
+		height: height,
 
-	var currchar rune
-	var currkey keyboard.Key
-	var err error
+		difficulty: settings.Difficulty,
+		mode:       settings.Mode,
+		level:      level,
+		pilotKind:  settings.Autopilot,
 
-	go func() {
-		for {
-			currchar, currkey, err = keyboard.GetKey()
-			if err != nil {
-				panic(err)
-			}
+		rng:   rand.New(rand.NewSource(seed)),
+		seed:  seed,
+		clock: systemClock{},
+	}
+	for i, start := range level.starts(width, height, settings.Players) {
+		dir := startDirection(i)
+		s := &Snake{
+			name:    players[i].name,
+			style:   players[i].style,
+			body:    startingBody(start, dir),
+			dir:     dir,
+			effects: map[FoodKind]int{},
 		}
-	}()
+		s.face(dir)
+		g.snakes = append(g.snakes, s)
+	}
+	g.GenerateFood()
+	return g
+}
 
+// Run plays until the snake crashes, a two-player round is over or the
+// player quits, drawing the frame whenever a step changes it. Key presses
+// arrive on g.input and are queued; each tick applies at most one queued
+// turn per snake.
+func (g *Game) Run() Outcome {
 	for {
-
-		if g.score > 2 {
-			g.speed = 600
-		} else if g.score > 5 {
-			g.speed = 555
-		} else if g.score > 10 {
-			g.speed = 400
-		} else if g.score > 15 {
-			g.speed = 300
-		} else if g.score > 20 {
-			g.speed = 200
-		} else if g.score > 30 {
-			g.speed = 100
+		outcome, frame := g.Step(g.input, g.clock)
+		if frame == Invalidated {
+			g.screen.Invalidate()
+		}
+		if frame != Unchanged {
+			g.Draw()
+		}
+		if outcome != Playing {
+			return outcome
 		}
+	}
+}
 
-		time.Sleep(g.speed * time.Millisecond)
+// face points the head and body glyphs in direction d.
+func (s *Snake) face(d Direction) {
+	switch d {
+	case Up:
+		s.head, s.bodyShape = "^", "|"
+	case Down:
+		s.head, s.bodyShape = "v", "|"
+	case Left:
+		s.head, s.bodyShape = "<", "-"
+	case Right:
+		s.head, s.bodyShape = ">", "-"
+	}
+}
 
-		if currkey == keyboard.KeyEsc || currchar == 'q' || currchar == 'Q' {
-			os.Exit(0)
+// Update moves every snake one cell and reports whether the game goes on.
+// The snakes move at the same time: a snake that runs into the other's
+// body dies, and two heads meeting kill both.
+func (g *Game) Update() Outcome {
+	alive := g.alive()
+	heads := map[*Snake]Coord{}
+	for _, s := range alive {
+		before := s.dir
+		if s.pilot != nil {
+			s.dir = s.pilot.Steer(g, s)
+		} else {
+			s.dir = s.turns.Next(s.dir)
 		}
+		if s.dir != before {
+			g.recordTurn(s)
+		}
+		s.face(s.dir)
+		if head, crash := g.nextHead(s); crash != Playing {
+			s.crash = crash
+		} else {
+			heads[s] = head
+		}
+	}
 
-		if currchar == 'w' || currchar == 'W' || currkey == keyboard.KeyArrowUp {
-			dir = "up"
-			g.snake.head = "^"
-			g.snake.bodyShape = "|"
-		} else if currchar == 'a' || currchar == 'A' || currkey == keyboard.KeyArrowLeft {
-			dir = "left"
-			g.snake.head = "<"
-			g.snake.bodyShape = "-"
-		} else if currchar == 's' || currchar == 'S' || currkey == keyboard.KeyArrowDown {
-			dir = "down"
-			g.snake.head = "v"
-			g.snake.bodyShape = "|"
-		} else if currchar == 'd' || currchar == 'D' || currkey == keyboard.KeyArrowRight {
-			dir = "right"
-			g.snake.head = ">"
-			g.snake.bodyShape = "-"
+	for i, a := range alive {
+		for _, b := range alive[i+1:] {
+			ha, okA := heads[a]
+			hb, okB := heads[b]
+			if okA && okB && (ha == hb || (ha == b.body[0] && hb == a.body[0])) {
+				a.crash, b.crash = HeadOn, HeadOn
+			}
 		}
+	}
 
-		if currchar == 0 && currkey == 0 {
-			dir = prevdir
+	for _, s := range alive {
+		if s.crash != Playing {
+			continue
+		}
+		s.body = append([]Coord{heads[s]}, s.body...)
+		grows := false
+		if f := g.foodAt(heads[s]); f != nil {
+			grows = g.eat(s, f)
+		}
+		if !grows {
+			s.body = s.body[:len(s.body)-1]
 		}
+	}
 
-		prevdir = dir
+	for _, s := range alive {
+		if s.crash != Playing {
+			continue
+		}
+		if s.effects[GhostPill] == 0 && s.HitsItself() {
+			s.crash = HitSelf
+		}
+		for _, other := range g.snakes {
+			if other != s && other.Contains(s.body[0]) {
+				s.crash = HitSnake
+			}
+		}
+	}
+	g.tickFood()
+	g.tick++
 
-		g.Update(dir)
-		g.Draw()
+	if len(g.snakes) == 1 {
+		return g.snakes[0].crash
+	}
+	if len(g.alive()) <= 1 {
+		return RoundOver
 	}
+	return Playing
 }
 
-func (g *Game) Update(dir string) {
-
-	head := g.snake.body[0]
+// nextHead returns where the snake's head moves this tick, or why the
+// move crashes.
+func (g *Game) nextHead(s *Snake) (Coord, Outcome) {
+	if next, ok := g.step(s.body[0], s.dir); ok {
+		return next, Playing
+	}
+	return s.body[0], HitWall
+}
 
-	var newHead Coord
+// step returns the cell one move from p in direction d, through the arena
+// edge in portals mode and through the level's portals. It reports false
+// when the move runs into a wall.
+func (g *Game) step(p Coord, d Direction) (Coord, bool) {
+	var next Coord
+
+	switch d {
+	case Up:
+		next = Coord{p.x, p.y - 1}
+	case Down:
+		next = Coord{p.x, p.y + 1}
+	case Left:
+		next = Coord{p.x - 1, p.y}
+	case Right:
+		next = Coord{p.x + 1, p.y}
+	}
 
-	switch dir {
-	case "up":
-		newHead = Coord{head.x, head.y - 1}
-	case "down":
-		newHead = Coord{head.x, head.y + 1}
-	case "left":
-		newHead = Coord{head.x - 1, head.y}
-	case "right":
-		newHead = Coord{head.x + 1, head.y}
+	if g.mode == Portals {
+		next = g.wrap(next)
+	} else if next.x < 0 || next.x >= g.width || next.y < 0 || next.y >= g.height {
+		return p, false
 	}
+	if exit, ok := g.level.Portals[next]; ok {
+		next = exit
+	}
+	if g.level.Walls[next] {
+		return p, false
+	}
+	return next, true
+}
 
-	if newHead.x < 0 || newHead.x >= g.width || newHead.y < 0 || newHead.y >= g.height {
-		fmt.Println("You hit the wall!")
-		fmt.Printf("Your score: %d\n", g.score)
-		os.Exit(0)
+// alive returns the snakes that have not crashed.
+func (g *Game) alive() []*Snake {
+	var alive []*Snake
+	for _, s := range g.snakes {
+		if s.crash == Playing {
+			alive = append(alive, s)
+		}
 	}
+	return alive
+}
 
-	g.snake.body = append([]Coord{newHead}, g.snake.body...)
+// snakeAt returns the snake with a segment on p, and whether p is its head.
+func (g *Game) snakeAt(p Coord) (*Snake, bool) {
+	for _, s := range g.snakes {
+		if s.body[0] == p {
+			return s, true
+		}
+	}
+	for _, s := range g.snakes {
+		if s.Contains(p) {
+			return s, false
+		}
+	}
+	return nil, false
+}
 
-	if g.food.pos == newHead {
-		g.score++
-		g.speed -= 5
-		g.GenerateFood()
-	} else {
-		g.snake.body = g.snake.body[:len(g.snake.body)-1]
+// bestScore is the highest score of any snake, which sets the speed.
+func (g *Game) bestScore() int {
+	best := 0
+	for _, s := range g.snakes {
+		if s.score > best {
+			best = s.score
+		}
 	}
+	return best
+}
 
-	if g.IsGameOver() {
-		fmt.Println("Game Over!")
-		fmt.Printf("Your score: %d\n", g.score)
-		os.Exit(0)
+// tickInterval is the time between ticks at the best score, doubled while
+// any snake has slow motion on.
+func (g *Game) tickInterval() time.Duration {
+	interval := g.difficulty.Interval(g.bestScore())
+	for _, s := range g.snakes {
+		if s.effects[SlowMotion] > 0 {
+			interval *= 2
+			break
+		}
 	}
+	return interval
 }
 
+// wrap moves a position that left the arena to the opposite edge.
+func (g *Game) wrap(p Coord) Coord {
+	p.x = (p.x + g.width) % g.width
+	p.y = (p.y + g.height) % g.height
+	return p
+}
+
+// BorderContains reports whether p is on the border drawn around the
+// playable area.
 func (g *Game) BorderContains(p Coord) bool {
+	inX := p.x >= -1 && p.x <= g.width
+	inY := p.y >= -1 && p.y <= g.height
+	return (inX && (p.y == -1 || p.y == g.height)) || (inY && (p.x == -1 || p.x == g.width))
+}
 
-	if p.x == 0 || p.y == 0 {
-		return true
+// borderRune returns the box-drawing character for a border cell.
+func (g *Game) borderRune(p Coord) string {
+	left, right := p.x == -1, p.x == g.width
+	top, bottom := p.y == -1, p.y == g.height
+	switch {
+	case top && left:
+		return "┌"
+	case top && right:
+		return "┐"
+	case bottom && left:
+		return "└"
+	case bottom && right:
+		return "┘"
+	case top || bottom:
+		return "─"
 	}
+	return "│"
+}
 
-	return true
+// fitsTerminal reports whether the whole frame fits the terminal.
+func (g *Game) fitsTerminal() bool {
+	cols, rows, err := terminalSize()
+	return err != nil || (cols >= g.width+2 && rows >= g.height+hudRows+2)
 }
 
+// Draw renders the score line and the board into the screen's back buffer
+// and flushes the cells that changed since the last tick. The board is
+// drawn one cell in from the frame's edge to leave room for the border.
 func (g *Game) Draw() {
-	cmd := exec.Command("clear")
-	cmd.Stdout = os.Stdout
-	cmd.Run()
-
-	fmt.Printf("Score: %d\n", g.score)
-
-	headX := g.snake.body[0].x
-	headY := g.snake.body[0].y
-
-	if headX < 0 {
-		headX = g.width - 1
-	} else if headX >= g.width {
-		headX = 0
+	s := g.screen
+	hud := fmt.Sprintf("Score: %d  Level: %s  Difficulty: %s  Mode: %s", g.snakes[0].score, g.level.Name, g.difficulty.Name, g.mode)
+	if len(g.snakes) > 1 {
+		hud = fmt.Sprintf("P1: %d  P2: %d  Round %d (%d-%d)  Level: %s  Mode: %s",
+			g.snakes[0].score, g.snakes[1].score, g.round, g.wins[0], g.wins[1], g.level.Name, g.mode)
+	}
+	switch {
+	case g.demo:
+		hud = fmt.Sprintf("DEMO  Score: %d  Level: %s  -  press any key", g.snakes[0].score, g.level.Name)
+	case g.snakes[0].pilot != nil && g.snakes[0].assisted:
+		hud += "  [AUTOPILOT]"
 	}
-	if headY < 0 {
-		headY = g.height - 1
-	} else if headY >= g.height {
-		headY = 0
+	status := strings.TrimSpace(g.caption + "  " + g.foodHUD())
+	width := g.width + 2
+	for _, line := range []string{hud, status} {
+		if len([]rune(line)) > width {
+			width = len([]rune(line))
+		}
+	}
+	s.Clear(width, g.height+hudRows+2)
+	s.Text(0, 0, hud, "")
+	s.Text(0, 1, status, "")
+
+	borderStyle := ""
+	if g.mode == Portals {
+		borderStyle = "\033[36m"
 	}
 
-	for i := 0; i < g.height; i++ {
-		for j := 0; j < g.width; j++ {
+	for i := -1; i <= g.height; i++ {
+		for j := -1; j <= g.width; j++ {
 			p := Coord{j, i}
 
+			var ch string
+			style := ""
+			snake, isHead := g.snakeAt(p)
 			switch {
-			case p == Coord{headX, headY}:
-				fmt.Print(g.snake.head)
-			case g.snake.Contains(p):
+			case isHead:
+				ch, style = snake.head, snake.drawStyle()
+			case snake != nil:
 				if (p.x+p.y)%2 == 0 {
-					g.snake.bodyShape = "\\"
+					snake.bodyShape = "\\"
 				} else {
-					g.snake.bodyShape = "/"
+					snake.bodyShape = "/"
 				}
-				fmt.Print(g.snake.bodyShape)
-			case g.food.pos == p:
-				fmt.Print(g.food.symbol)
+				ch, style = snake.bodyShape, snake.drawStyle()
+			case g.foodAt(p) != nil:
+				spec := g.foodAt(p).spec()
+				ch, style = spec.glyph, spec.style
+			case g.level.Walls[p]:
+				ch = "█"
+			case g.level.isPortal(p):
+				ch, style = "@", "\033[35m"
 			case g.BorderContains(p):
-				fmt.Print("#")
+				ch = g.borderRune(p)
+				style = borderStyle
 			default:
-				fmt.Print(" ")
+				ch = " "
 			}
+			s.Text(j+1, i+hudRows+1, ch, style)
 		}
-		fmt.Println()
 	}
+
+	g.drawOverlay()
+	s.Flush()
 }
 
-func (g *Game) GenerateFood() {
-	var x, y int
-	for {
-		x = rand.Intn(g.width)
-		y = rand.Intn(g.height)
+// Choice is what the player picks on the game over screen.
+type Choice int
 
-		if !g.snake.Contains(Coord{x, y}) {
-			break
+const (
+	PlayAgain Choice = iota
+	ChooseLevel
+	QuitGame
+)
+
+// ShowResults draws the game over panel over the final board and waits for
+// the player to choose what to do next.
+func (g *Game) ShowResults(outcome Outcome, history *ScoreHistory, newHighScore bool) Choice {
+	snake := g.snakes[0]
+	highScore := fmt.Sprintf("High score: %d", history.HighScores[g.mode].Score)
+	if newHighScore {
+		highScore += "  NEW!"
+	} else if snake.assisted {
+		highScore += "  (autopilot, not recorded)"
+	}
+	g.Draw()
+	g.screen.Panel([]string{
+		"GAME OVER",
+		outcome.String(),
+		fmt.Sprintf("Level:      %s", g.level.Name),
+		fmt.Sprintf("Mode:       %s", g.mode),
+		"",
+		fmt.Sprintf("Score:      %d", snake.score),
+		highScore,
+		fmt.Sprintf("Length:     %d", len(snake.body)),
+		fmt.Sprintf("Seed:       %d", g.seed),
+		"",
+		"ENTER/R: play again",
+		"L:       choose a level",
+		"Q:       quit",
+	}, "")
+	g.screen.Flush()
+	return g.waitForChoice()
+}
+
+// waitForChoice reads keys until the player picks what to do next.
+func (g *Game) waitForChoice() Choice {
+	for ev := range g.input.Keys {
+		switch {
+		case ev.Err != nil || isQuitKey(ev):
+			return QuitGame
+		case ev.Key == keyboard.KeyEnter || ev.Rune == 'r' || ev.Rune == 'R':
+			return PlayAgain
+		case ev.Rune == 'l' || ev.Rune == 'L':
+			return ChooseLevel
 		}
 	}
-	g.food.pos = Coord{x, y}
+	return QuitGame
 }
 
-func (g *Game) IsGameOver() bool {
-	head := g.snake.body[0]
-	for _, v := range g.snake.body[1:] {
+// HitsItself reports whether the head is on one of the snake's own
+// segments.
+func (s *Snake) HitsItself() bool {
+	head := s.body[0]
+	for _, v := range s.body[1:] {
 		if v == head {
 			return true
 		}
@@ -245,7 +579,15 @@ func (g *Game) IsGameOver() bool {
 	return false
 }
 
-func (s Snake) Contains(c Coord) bool {
+// drawStyle is the snake's color, dimmed while it is a ghost.
+func (s *Snake) drawStyle() string {
+	if s.effects[GhostPill] > 0 {
+		return s.style + "\033[2m"
+	}
+	return s.style
+}
+
+func (s *Snake) Contains(c Coord) bool {
 	for _, v := range s.body {
 		if v == c {
 			return true
@@ -258,68 +600,103 @@ func main() {
 
 	log.SetFlags(3 | 16)
 
-	cmd := exec.Command("clear")
-	cmd.Stdout = os.Stdout
-	cmd.Run()
+	preset := flag.String("difficulty", "normal", "difficulty `preset`: "+strings.Join(presetNames(), ", "))
+	curve := flag.String("curve", "", "custom speed `curve` as score:milliseconds pairs, e.g. 0:300,10:200,25:120")
+	modeName := flag.String("mode", "walls", "arena `mode`: walls, or portals to wrap around the edges")
+	levelsDir := flag.String("levels", getLevelsDir(), "`directory` of extra level files (*.txt)")
+	playerCount := flag.Int("players", 1, "number of `players`: 1, or 2 for a head-to-head match")
+	rounds := flag.Int("rounds", 3, "`rounds` a player must win to take a two-player match")
+	pilotName := flag.String("autopilot", "path", "autopilot `strategy`: path, or cycle to follow a Hamiltonian cycle")
+	seed := flag.Int64("seed", 0, "random `seed` of the first game, as shown when a game ends; later games count up from it, and 0 picks one from the clock")
+	record := flag.String("record", getReplayPath(), "`file` the last game is recorded to")
+	replayFile := flag.String("replay", "", "play back the recorded game in `file`")
+	flag.Parse()
+
+	if *playerCount < 1 || *playerCount > len(players) {
+		fmt.Fprintf(os.Stderr, "players must be 1 or %d\n", len(players))
+		os.Exit(2)
+	}
+	if *rounds < 1 {
+		fmt.Fprintln(os.Stderr, "rounds must be at least 1")
+		os.Exit(2)
+	}
 
-	intro := `  
-	Go________              __           
-	/   _____/ ____ _____  |  | __ ____  
-	\_____  \ /    \\__  \ |  |/ // __ \ 
-	/  ___   \  ||  \/ __ \|    <\  ___/ 
-       /_______  /__||  (____  /__|_ \\___  >
-               \/     \/     \/     \/    \/ 
-	     	           by SAPPHIRE_KNIGHT
-							  
-	Press ENTER to START a New Game 
-	Press Q to QUIT the Game 
-	
-	
+	difficulty, err := findPreset(*preset)
+	if *curve != "" {
+		difficulty, err = parseCurve(*curve)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	mode, err := parseMode(*modeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
+	pilot, err := parsePilot(*pilotName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-                      Developed in GoLang`
+	levels, err := loadLevels(*levelsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	startFlag := false
-	MaxLoader := 5
+	var playback *Playback
+	if *replayFile != "" {
+		replay, err := loadReplay(*replayFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		level, err := findLevel(levels, replay.Level)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		playback = NewPlayback(replay, level)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
 
-	err := keyboard.Open()
+	screen := NewScreen(os.Stdout)
+	screen.Start()
+	defer screen.Restore()
+
+	keys, err := keyboard.GetKeys(10)
 	if err != nil {
 		panic(err)
 	}
 	defer keyboard.Close()
 
-	go func() {
-		for {
-
-			char, key, err := keyboard.GetKey()
-			if err != nil {
-				panic(err)
-			}
-
-			if key == keyboard.KeyEnter {
-				startFlag = true
-				return
-			} else if char == 'q' || char == 'Q' {
-				println()
-				os.Exit(0)
-			}
-		}
-	}()
-
-	for !startFlag {
-		fmt.Print(intro)
-		for i := 0; i < MaxLoader; i++ {
-			fmt.Print(".")
-			time.Sleep(500 * time.Millisecond)
-			if startFlag {
-				break
-			}
-		}
-		cmd := exec.Command("clear")
-		cmd.Stdout = os.Stdout
-		cmd.Run()
+	if playback != nil {
+		g := playback.game
+		g.screen = screen
+		g.input = Input{Keys: keys, Resized: notifyResize()}
+		playback.Run()
+		return
 	}
 
-	g := NewGame()
-	g.Run()
+	session := &Session{
+		screen:  screen,
+		keys:    keys,
+		resized: notifyResize(),
+		history: loadScoreHistory(),
+		seed:    *seed,
+		record:  *record,
+		settings: Settings{
+			Difficulty:  difficulty,
+			Mode:        mode,
+			Players:     *playerCount,
+			RoundsToWin: *rounds,
+			Autopilot:   pilot,
+		},
+	}
+	session.Run(levels)
 }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eiannone/keyboard"
+)
+
+const title = `
+	Go________              __
+	/   _____/ ____ _____  |  | __ ____
+	\_____  \ /    \\__  \ |  |/ // __ \
+	/  ___   \  ||  \/ __ \|    <\  ___/
+       /_______  /__||  (____  /__|_ \\___  >
+               \/     \/     \/     \/    \/
+	     	           by SAPPHIRE_KNIGHT
+`
+
+// demoDelay is how long the menu waits for a key before the autopilot
+// starts playing the selected level as an attract-mode demo.
+const demoDelay = 10 * time.Second
+
+var demoDifficulty = Difficulty{Name: "demo", Curve: []SpeedStep{{0, 60 * time.Millisecond}}}
+
+// Menu is the start screen: the title and a list of levels to choose from.
+type Menu struct {
+	screen   *Screen
+	keys     <-chan keyboard.KeyEvent
+	resized  <-chan os.Signal
+	levels   []*Level
+	selected int
+}
+
+// Run shows the level list until the player starts a level or quits, and
+// stores the chosen level and number of players in settings. The last
+// choice stays selected the next time the menu is shown. Left alone, the
+// menu plays demo games until a key is pressed.
+func (m *Menu) Run(settings *Settings) bool {
+	for {
+		m.draw(settings)
+
+		select {
+		case ev, ok := <-m.keys:
+			if !ok || ev.Err != nil || isQuitKey(ev) {
+				return false
+			}
+			if ev.Key == keyboard.KeyEnter {
+				settings.Level = m.levels[m.selected]
+				return true
+			}
+			if ev.Rune >= '1' && int(ev.Rune-'0') <= len(players) {
+				settings.Players = int(ev.Rune - '0')
+			}
+			switch d, _ := keyDirection(ev); d {
+			case Up:
+				m.selected = (m.selected + len(m.levels) - 1) % len(m.levels)
+			case Down:
+				m.selected = (m.selected + 1) % len(m.levels)
+			}
+		case <-m.resized:
+			m.screen.Invalidate()
+		case <-time.After(demoDelay):
+			m.demo(settings)
+			m.screen.Invalidate()
+		}
+	}
+}
+
+// demo lets the autopilot play the selected level, one game after another,
+// until a key is pressed or the terminal is resized.
+func (m *Menu) demo(settings *Settings) {
+	demo := *settings
+	demo.Level, demo.Players, demo.Difficulty = m.levels[m.selected], 1, demoDifficulty
+	for {
+		width, height := arenaSize()
+		g := NewGame(demo, width, height, time.Now().UnixNano())
+		g.screen = m.screen
+		g.input = Input{Keys: m.keys, Resized: m.resized}
+		g.demo = true
+		g.snakes[0].pilot = g.newPilot(demo.Autopilot, g.snakes[0])
+		if g.Run() == Quit {
+			return
+		}
+	}
+}
+
+func (m *Menu) draw(settings *Settings) {
+	lines := strings.Split(expandTabs(title), "\n")
+	lines = append(lines, "        Choose a level:", "")
+	for i, level := range m.levels {
+		size := "fits the terminal"
+		if level.Width > 0 {
+			size = fmt.Sprintf("%dx%d", level.Width, level.Height)
+		}
+		cursor := " "
+		if i == m.selected {
+			cursor = ">"
+		}
+		lines = append(lines, fmt.Sprintf("      %s %-20s %s", cursor, level.Name, size))
+	}
+	lines = append(lines,
+		"",
+		fmt.Sprintf("        Difficulty: %s   Mode: %s   Players: %d", settings.Difficulty.Name, settings.Mode, settings.Players),
+		"",
+		"        Up/Down to choose, ENTER to START, Q to QUIT",
+		"        1 or 2 for the number of players",
+		"        Press H in game for HELP, I for the AUTOPILOT",
+		"",
+		"                      Developed in GoLang",
+	)
+
+	width := 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	s := m.screen
+	s.Clear(width, len(lines))
+	for y, line := range lines {
+		style := ""
+		if strings.HasPrefix(line, "      >") {
+			style = "\033[1m"
+		}
+		s.Text(0, y, line, style)
+	}
+	s.Flush()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/eiannone/keyboard"
+)
+
+// Overlay is a panel drawn over the arena. While one is shown the tick
+// clock is stopped.
+type Overlay int
+
+const (
+	NoOverlay Overlay = iota
+	PauseOverlay
+	HelpOverlay
+	ResizeOverlay
+)
+
+// handleKey applies a key press that is not a quit key, opening or closing
+// overlays or queueing a turn.
+func (g *Game) handleKey(ev keyboard.KeyEvent) {
+	switch g.overlay {
+	case NoOverlay:
+		switch {
+		case isPauseKey(ev):
+			g.overlay = PauseOverlay
+		case isHelpKey(ev):
+			g.overlay = HelpOverlay
+		case isPilotKey(ev):
+			g.togglePilot()
+		default:
+			if d, ok := keyDirection(ev); ok {
+				s := g.snakes[0]
+				if len(g.snakes) > 1 && isArrowKey(ev) {
+					s = g.snakes[1]
+				}
+				s.turns.Push(s.dir, d)
+			}
+		}
+	case PauseOverlay:
+		switch {
+		case isHelpKey(ev):
+			g.overlay = HelpOverlay
+		case isPauseKey(ev):
+			g.resume()
+		}
+	case HelpOverlay:
+		if isHelpKey(ev) || isPauseKey(ev) {
+			g.resume()
+		}
+	case ResizeOverlay:
+		g.resume()
+	}
+}
+
+// resume closes the overlay, unless the arena no longer fits the terminal.
+func (g *Game) resume() {
+	if g.fitsTerminal() {
+		g.overlay = NoOverlay
+	}
+}
+
+func (g *Game) drawOverlay() {
+	s := g.screen
+	if g.overlay != NoOverlay && !g.fitsTerminal() {
+		s.Panel([]string{"Terminal too small", fmt.Sprintf("Need %dx%d for this arena.", g.width+2, g.height+hudRows+2)}, "")
+		return
+	}
+
+	switch g.overlay {
+	case PauseOverlay:
+		s.Panel([]string{"PAUSED", "", "Space/P: resume", "H:       help"}, "")
+	case HelpOverlay:
+		s.Panel([]string{
+			"HELP",
+			"",
+			"WASD/Arrows  steer (P1/P2 in a match)",
+			"I            autopilot on/off (P1)",
+			"Space/P      pause",
+			"H            this help",
+			"Q/Esc        quit",
+			"",
+			"*  food       $  bonus",
+			"%  shrink     ~  slow motion",
+			"&  ghost",
+			"",
+			fmt.Sprintf("Difficulty   %s (%v/tick)", g.difficulty.Name, g.speed),
+			fmt.Sprintf("Mode         %s", g.mode),
+			fmt.Sprintf("Autopilot    %s", g.pilotKind),
+			fmt.Sprintf("Arena        %dx%d", g.width, g.height),
+		}, "")
+	case ResizeOverlay:
+		s.Panel([]string{"PAUSED", "The terminal was resized.", "Press any key to resume."}, "")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANSI escape sequences used by the renderer.
+const (
+	ansiHome       = "\033[H"
+	ansiClear      = "\033[2J"
+	ansiReset      = "\033[0m"
+	ansiHideCursor = "\033[?25l"
+	ansiShowCursor = "\033[?25h"
+)
+
+// Cell is one character on screen with its ANSI style (an SGR sequence
+// such as "\033[32m", or "" for the default colors).
+type Cell struct {
+	ch    rune
+	style string
+}
+
+var blankCell = Cell{ch: ' '}
+
+// Screen is a double-buffered terminal frame. Drawing writes into the back
+// buffer; Flush compares it with what is on screen and writes only the
+// cells that changed, in a single write.
+type Screen struct {
+	out           io.Writer
+	width, height int
+	front, back   [][]Cell
+	fullRedraw    bool
+}
+
+func NewScreen(out io.Writer) *Screen {
+	return &Screen{out: out, fullRedraw: true}
+}
+
+// Start hides the cursor and clears the terminal.
+func (s *Screen) Start() {
+	fmt.Fprint(s.out, ansiHideCursor+ansiClear+ansiHome)
+}
+
+// Restore puts the terminal back the way it was: default colors, visible
+// cursor, and the cursor on the line below the last frame.
+func (s *Screen) Restore() {
+	fmt.Fprintf(s.out, "%s\033[%d;1H%s\n", ansiReset, s.height+1, ansiShowCursor)
+}
+
+// Clear blanks the back buffer, growing it to hold width x height cells.
+func (s *Screen) Clear(width, height int) {
+	if width != s.width || height != s.height {
+		s.width, s.height = width, height
+		s.front = makeCells(width, height)
+		s.back = makeCells(width, height)
+		s.fullRedraw = true
+	}
+	for y := range s.back {
+		for x := range s.back[y] {
+			s.back[y][x] = blankCell
+		}
+	}
+}
+
+func makeCells(width, height int) [][]Cell {
+	cells := make([][]Cell, height)
+	for y := range cells {
+		cells[y] = make([]Cell, width)
+	}
+	return cells
+}
+
+// Set draws a single character; positions outside the frame are ignored.
+func (s *Screen) Set(x, y int, ch rune, style string) {
+	if y < 0 || y >= s.height || x < 0 || x >= s.width {
+		return
+	}
+	s.back[y][x] = Cell{ch: ch, style: style}
+}
+
+// Text draws a string starting at x, y.
+func (s *Screen) Text(x, y int, text string, style string) {
+	for _, ch := range text {
+		s.Set(x, y, ch, style)
+		x++
+	}
+}
+
+// Flush writes the changed cells to the terminal and makes the back buffer
+// the new front buffer.
+func (s *Screen) Flush() error {
+	var buf bytes.Buffer
+	if s.fullRedraw {
+		buf.WriteString(ansiClear)
+	}
+	buf.WriteString(ansiHome)
+
+	style := ""
+	buf.WriteString(ansiReset)
+	for y := 0; y < s.height; y++ {
+		cursorX := -1
+		for x := 0; x < s.width; x++ {
+			cell := s.back[y][x]
+			if !s.fullRedraw && cell == s.front[y][x] {
+				continue
+			}
+			if cursorX != x {
+				fmt.Fprintf(&buf, "\033[%d;%dH", y+1, x+1)
+			}
+			if cell.style != style {
+				buf.WriteString(ansiReset + cell.style)
+				style = cell.style
+			}
+			buf.WriteRune(cell.ch)
+			cursorX = x + 1
+			s.front[y][x] = cell
+		}
+	}
+	if style != "" {
+		buf.WriteString(ansiReset)
+	}
+	s.fullRedraw = false
+
+	_, err := s.out.Write(buf.Bytes())
+	return err
+}
+
+// expandTabs replaces tabs with spaces up to the next multiple of eight
+// columns, since the screen places every rune in a single cell.
+func expandTabs(s string) string {
+	var sb strings.Builder
+	col := 0
+	for _, ch := range s {
+		switch ch {
+		case '\t':
+			n := 8 - col%8
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n':
+			sb.WriteRune(ch)
+			col = 0
+		default:
+			sb.WriteRune(ch)
+			col++
+		}
+	}
+	return sb.String()
+}
+
+// Panel draws lines of text in a bordered box centered on the frame.
+func (s *Screen) Panel(lines []string, style string) {
+	inner := 0
+	for _, line := range lines {
+		if n := len([]rune(line)); n > inner {
+			inner = n
+		}
+	}
+	w, h := inner+4, len(lines)+2
+	x0, y0 := (s.width-w)/2, (s.height-h)/2
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ch := ' '
+			switch {
+			case (y == 0 || y == h-1) && (x == 0 || x == w-1):
+				ch = '+'
+			case y == 0 || y == h-1:
+				ch = '-'
+			case x == 0 || x == w-1:
+				ch = '|'
+			}
+			s.Set(x0+x, y0+y, ch, style)
+		}
+	}
+	for i, line := range lines {
+		s.Text(x0+2, y0+1+i, line, style)
+	}
+}
+
+// Invalidate makes the next Flush redraw every cell, for when the terminal
+// contents can no longer be trusted, such as after a resize.
+func (s *Screen) Invalidate() {
+	s.fullRedraw = true
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eiannone/keyboard"
+)
+
+// Replay is a recorded game: everything needed to play it again tick for
+// tick. The food is placed by a generator seeded with Seed, so replaying
+// the same turns on the same arena reproduces the whole game.
+type Replay struct {
+	Seed       int64      `json:"seed"`
+	Difficulty Difficulty `json:"difficulty"`
+	Mode       Mode       `json:"mode"`
+	Level      string     `json:"level"`
+	Players    int        `json:"players"`
+	Width      int        `json:"width"`
+	Height     int        `json:"height"`
+	Ticks      int        `json:"ticks"`
+	Turns      []Turn     `json:"turns"`
+}
+
+// Turn is a change of direction by one player, applied on the given tick.
+type Turn struct {
+	Tick   int       `json:"tick"`
+	Player int       `json:"player"`
+	Dir    Direction `json:"dir"`
+}
+
+// newReplay starts recording a game that has not been played yet.
+func newReplay(g *Game) *Replay {
+	return &Replay{
+		Seed:       g.seed,
+		Difficulty: g.difficulty,
+		Mode:       g.mode,
+		Level:      g.level.Name,
+		Players:    len(g.snakes),
+		Width:      g.width,
+		Height:     g.height,
+	}
+}
+
+// recordTurn notes that snake s changed direction this tick.
+func (g *Game) recordTurn(s *Snake) {
+	if g.replay == nil {
+		return
+	}
+	for i, snake := range g.snakes {
+		if snake == s {
+			g.replay.Turns = append(g.replay.Turns, Turn{Tick: g.tick, Player: i, Dir: s.dir})
+		}
+	}
+}
+
+func getReplayPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "last-replay.json"
+	}
+	return filepath.Join(homeDir, ".local", "share", "go-snake", "last-replay.json")
+}
+
+func saveReplay(path string, r *Replay) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	return os.WriteFile(path, data, 0644)
+}
+
+func loadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(r.Difficulty.Curve) == 0 || r.Players < 1 || r.Players > len(players) || r.Width < 1 || r.Height < 1 {
+		return nil, fmt.Errorf("%s: not a go-snake replay", path)
+	}
+	return r, nil
+}
+
+// findLevel returns the level a replay was played on.
+func findLevel(levels []*Level, name string) (*Level, error) {
+	for _, level := range levels {
+		if level.Name == name {
+			return level, nil
+		}
+	}
+	return nil, fmt.Errorf("the replay was played on level %q, which is not installed", name)
+}
+
+// ReplayPilot steers a snake through the turns of a recording.
+type ReplayPilot struct {
+	turns map[int]Direction
+}
+
+func (p *ReplayPilot) Steer(g *Game, s *Snake) Direction {
+	if d, ok := p.turns[g.tick]; ok {
+		return d
+	}
+	return s.dir
+}
+
+// maxReplaySpeed is the fastest fast-forward, as a multiple of the
+// recorded speed.
+const maxReplaySpeed = 16
+
+// Playback shows a recorded game frame by frame. It can be paused, stepped
+// a tick at a time while paused, and fast-forwarded.
+type Playback struct {
+	game   *Game
+	replay *Replay
+	paused bool
+	speed  int
+}
+
+// NewPlayback sets up the recorded game with every snake steered by the
+// recording.
+func NewPlayback(r *Replay, level *Level) *Playback {
+	settings := Settings{Difficulty: r.Difficulty, Mode: r.Mode, Level: level, Players: r.Players}
+	g := NewGame(settings, r.Width, r.Height, r.Seed)
+	for i, s := range g.snakes {
+		pilot := &ReplayPilot{turns: map[int]Direction{}}
+		for _, turn := range r.Turns {
+			if turn.Player == i {
+				pilot.turns[turn.Tick] = turn.Dir
+			}
+		}
+		s.pilot = pilot
+	}
+	return &Playback{game: g, replay: r, speed: 1}
+}
+
+// Run plays the recording until the player quits.
+func (p *Playback) Run() {
+	g := p.game
+	outcome := Playing
+	for {
+		done := outcome != Playing || g.tick >= p.replay.Ticks
+		p.updateCaption(outcome, done)
+		g.Draw()
+
+		var tick <-chan time.Time
+		if !p.paused && !done {
+			tick = g.clock.After(g.tickInterval() / time.Duration(p.speed))
+		}
+		select {
+		case ev, ok := <-g.input.Keys:
+			switch {
+			case !ok || ev.Err != nil || isQuitKey(ev):
+				return
+			case isPauseKey(ev):
+				p.paused = !p.paused
+			case ev.Rune == 'f' || ev.Rune == 'F':
+				p.speed *= 2
+				if p.speed > maxReplaySpeed {
+					p.speed = 1
+				}
+			case (ev.Rune == 'n' || ev.Rune == 'N' || ev.Key == keyboard.KeyArrowRight) && p.paused && !done:
+				outcome = g.Update()
+			}
+		case <-g.input.Resized:
+			g.screen.Invalidate()
+		case <-tick:
+			outcome = g.Update()
+		}
+	}
+}
+
+func (p *Playback) updateCaption(outcome Outcome, done bool) {
+	g := p.game
+	switch {
+	case done && outcome == RoundOver:
+		g.caption = "REPLAY  round over  Q: quit"
+	case done && outcome != Playing:
+		g.caption = fmt.Sprintf("REPLAY  %s  Q: quit", outcome)
+	case done:
+		g.caption = "REPLAY  end of recording  Q: quit"
+	case p.paused:
+		g.caption = fmt.Sprintf("REPLAY  tick %d/%d  paused  Space: play  N: step  Q: quit", g.tick, p.replay.Ticks)
+	default:
+		g.caption = fmt.Sprintf("REPLAY  tick %d/%d  x%d  Space: pause  F: faster  Q: quit", g.tick, p.replay.Ticks, p.speed)
+	}
+}
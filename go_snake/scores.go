@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type GameRecord struct {
+	Mode   Mode      `json:"mode"`
+	Score  int       `json:"score"`
+	Length int       `json:"length"`
+	Date   time.Time `json:"date"`
+}
+
+// ScoreHistory keeps a high score for each mode, since wrapping around the
+// edges makes for much longer games than dying on the walls.
+type ScoreHistory struct {
+	HighScores map[Mode]GameRecord `json:"high_scores"`
+	AllPlays   []GameRecord        `json:"all_plays"`
+}
+
+func getScoreHistoryPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "score-history.json"
+	}
+	dataDir := filepath.Join(homeDir, ".local", "share", "go-snake")
+	os.MkdirAll(dataDir, 0755)
+	return filepath.Join(dataDir, "score-history.json")
+}
+
+func loadScoreHistory() *ScoreHistory {
+	history := &ScoreHistory{HighScores: map[Mode]GameRecord{}}
+	data, err := os.ReadFile(getScoreHistoryPath())
+	if err != nil {
+		return history
+	}
+
+	if err := json.Unmarshal(data, history); err != nil {
+		return &ScoreHistory{HighScores: map[Mode]GameRecord{}}
+	}
+	if history.HighScores == nil {
+		history.HighScores = map[Mode]GameRecord{}
+	}
+	return history
+}
+
+func saveScoreHistory(history *ScoreHistory) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getScoreHistoryPath(), data, 0644)
+}
+
+// record adds a finished game and reports whether it set a new high score
+// for its mode.
+func (h *ScoreHistory) record(mode Mode, score, length int) bool {
+	record := GameRecord{Mode: mode, Score: score, Length: length, Date: time.Now()}
+	h.AllPlays = append(h.AllPlays, record)
+	if score > h.HighScores[mode].Score {
+		h.HighScores[mode] = record
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eiannone/keyboard"
+)
+
+// Session runs games from the level select screen until the player quits.
+type Session struct {
+	screen   *Screen
+	keys     <-chan keyboard.KeyEvent
+	resized  <-chan os.Signal
+	history  *ScoreHistory
+	settings Settings
+	seed     int64  // the next game's seed; each game counts up by one
+	record   string // where the last game's replay is saved
+}
+
+func (s *Session) Run(levels []*Level) {
+	menu := &Menu{screen: s.screen, keys: s.keys, resized: s.resized, levels: levels}
+	for menu.Run(&s.settings) {
+		for {
+			var choice Choice
+			if s.settings.Players == 1 {
+				choice = s.playSolo()
+			} else {
+				choice = s.playMatch()
+			}
+			if choice == QuitGame {
+				return
+			}
+			if choice == ChooseLevel {
+				break
+			}
+		}
+	}
+}
+
+func (s *Session) newGame() *Game {
+	width, height := arenaSize()
+	g := NewGame(s.settings, width, height, s.seed)
+	s.seed++
+	g.screen = s.screen
+	g.input = Input{Keys: s.keys, Resized: s.resized}
+	g.replay = newReplay(g)
+	return g
+}
+
+// saveReplay records the game that just ended, replacing the last one.
+func (s *Session) saveReplay(g *Game) {
+	g.replay.Ticks = g.tick
+	saveReplay(s.record, g.replay)
+}
+
+// playSolo plays one single-player game and records its score, unless the
+// autopilot had a hand in it.
+func (s *Session) playSolo() Choice {
+	g := s.newGame()
+	outcome := g.Run()
+	s.saveReplay(g)
+	if outcome == Quit {
+		return QuitGame
+	}
+	snake := g.snakes[0]
+	newHighScore := false
+	if !snake.assisted {
+		newHighScore = s.history.record(g.mode, snake.score, len(snake.body))
+		saveScoreHistory(s.history)
+	}
+	return g.ShowResults(outcome, s.history, newHighScore)
+}
+
+// playMatch plays two-player rounds until one player has won
+// RoundsToWin of them. Drawn rounds count for nobody.
+func (s *Session) playMatch() Choice {
+	var wins [2]int
+	for round := 1; ; round++ {
+		g := s.newGame()
+		g.round, g.wins = round, wins
+		outcome := g.Run()
+		s.saveReplay(g)
+		if outcome == Quit {
+			return QuitGame
+		}
+
+		winner := g.roundWinner()
+		if winner >= 0 {
+			wins[winner]++
+		}
+		if winner >= 0 && wins[winner] == s.settings.RoundsToWin {
+			return g.ShowMatchResults(winner, wins)
+		}
+		if !g.ShowRound(winner, wins) {
+			return QuitGame
+		}
+	}
+}
+
+// roundWinner returns the index of the only snake left, or -1 when both
+// crashed on the same tick.
+func (g *Game) roundWinner() int {
+	for i, s := range g.snakes {
+		if s.crash == Playing {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Game) crashLines() []string {
+	var lines []string
+	for _, s := range g.snakes {
+		lines = append(lines, fmt.Sprintf("%s, %d points", s.crash.describe(s.name), s.score))
+	}
+	return lines
+}
+
+// ShowRound shows how the round ended and waits for the next round. It
+// reports false when the player quits.
+func (g *Game) ShowRound(winner int, wins [2]int) bool {
+	title := "Round drawn"
+	if winner >= 0 {
+		title = g.snakes[winner].name + " wins the round"
+	}
+	lines := append([]string{fmt.Sprintf("ROUND %d", g.round), title, ""}, g.crashLines()...)
+	lines = append(lines, "", fmt.Sprintf("Match: %d - %d", wins[0], wins[1]), "", "ENTER: next round", "Q:     quit")
+	g.Draw()
+	g.screen.Panel(lines, "")
+	g.screen.Flush()
+
+	for ev := range g.input.Keys {
+		switch {
+		case ev.Err != nil || isQuitKey(ev):
+			return false
+		case ev.Key == keyboard.KeyEnter:
+			return true
+		}
+	}
+	return false
+}
+
+// ShowMatchResults announces the winner of the match and waits for the
+// player to choose what to do next.
+func (g *Game) ShowMatchResults(winner int, wins [2]int) Choice {
+	lines := append([]string{"MATCH OVER", g.snakes[winner].name + " wins the match!", ""}, g.crashLines()...)
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Rounds: %d - %d", wins[0], wins[1]),
+		"",
+		"ENTER/R: rematch",
+		"L:       choose a level",
+		"Q:       quit",
+	)
+	g.Draw()
+	g.screen.Panel(lines, "")
+	g.screen.Flush()
+	return g.waitForChoice()
+}
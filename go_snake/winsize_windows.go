@@ -0,0 +1,18 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// terminalSize is not supported on Windows; the game falls back to its
+// default arena size.
+func terminalSize() (int, int, error) {
+	return 0, 0, errors.New("terminal size is not available")
+}
+
+// notifyResize returns a nil channel: Windows has no SIGWINCH, so resizes
+// are never reported.
+func notifyResize() <-chan os.Signal {
+	return nil
+}